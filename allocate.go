@@ -0,0 +1,52 @@
+package defaultz
+
+import (
+	"errors"
+	"reflect"
+)
+
+// AllocateNilStructs allocates the nil pointer-to-struct fields of the struct recursively, without applying any
+// default values. This is useful to be able to assign the fields of nested structs without nil checks.
+//
+// Fields that cannot be set, such as unexported fields, are skipped. It is cycle-safe: a pointer to a struct type
+// that is already being allocated in the current path, e.g. the Next field of a linked list node, is left nil.
+func AllocateNilStructs(obj interface{}) error {
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return errors.New("object must be a pointer to a struct")
+	}
+
+	allocateNilStructs(val.Elem(), make(map[reflect.Type]bool))
+	return nil
+}
+
+// allocateNilStructs allocates the nil pointer-to-struct fields of the struct value.
+// The seen map holds the struct types in the current recursion path.
+func allocateNilStructs(value reflect.Value, seen map[reflect.Type]bool) {
+	// Mark this type as seen for the current recursion path
+	seen[value.Type()] = true
+	defer delete(seen, value.Type()) // Remove after processing
+
+	for i := range value.NumField() {
+		fieldValue := value.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		switch {
+		case fieldValue.Kind() == reflect.Struct:
+			allocateNilStructs(fieldValue, seen)
+
+		case fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct:
+			elemType := fieldValue.Type().Elem()
+			if seen[elemType] {
+				// don't follow cycles, neither the ones in the type definition nor the ones in the values
+				continue
+			}
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(elemType))
+			}
+			allocateNilStructs(fieldValue.Elem(), seen)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package defaultz
+
+import (
+	"reflect"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ApplyDefaultsStrictComplete applies default values to the struct like [DefaulterRegistry.ApplyDefaults], and then
+// checks that the configuration is complete: every field that is still zero must have a default value, e.g.
+// `default:"0"`. The fields that are zero without a default value are reported with [ErrIncomplete] errors, one per
+// field, combined in a single error.
+//
+// The fields of nested structs, including the ones pointed by non-nil pointers, are checked one by one. Structs
+// without exported fields, such as time.Time, are checked as a whole. Unexported fields are not checked.
+// Pointers, collections, interfaces, channels and functions are not checked, unless added with
+// [WithStrictCompleteKinds].
+func (r *defaulterRegistry) ApplyDefaultsStrictComplete(obj interface{}) error {
+	if err := r.ApplyDefaults(obj); err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(obj).Elem()
+	var result *multierror.Error
+	if err := r.checkComplete(val, r.rootPath(val.Type()), &result); err != nil {
+		return err
+	}
+	return result.ErrorOrNil()
+}
+
+// checkComplete adds an [ErrIncomplete] error to the result for each field of the struct value that is zero
+// without a default value.
+func (r *defaulterRegistry) checkComplete(value reflect.Value, path string, result **multierror.Error) error {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		if !field.IsExported() || r.isSkipped(field) {
+			continue
+		}
+
+		_, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+		if err != nil {
+			return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+		}
+
+		// Handle nested structs (including non-nil pointers to structs) field by field
+		nested := fieldValue
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && !found && hasExportedFields(nested.Type()) {
+			if err := r.checkComplete(nested, addFieldToPath(path, field), result); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if found || !isZero(fieldValue) || !r.isCheckedForCompleteness(field.Type.Kind()) {
+			continue
+		}
+		*result = multierror.Append(*result,
+			NewError(nil, ErrIncomplete, path, field, "zero value without a default value"))
+	}
+	return nil
+}
+
+// isCheckedForCompleteness returns true if the fields of the kind are checked by ApplyDefaultsStrictComplete.
+func (r *defaulterRegistry) isCheckedForCompleteness(kind reflect.Kind) bool {
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch kind {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Array, reflect.Interface, reflect.Chan, reflect.Func,
+		reflect.UnsafePointer:
+		return r.strictCompleteKinds[kind]
+	default:
+		return true
+	}
+}
+
+// hasExportedFields returns true if the struct type has at least one exported field.
+func hasExportedFields(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
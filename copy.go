@@ -0,0 +1,92 @@
+package defaultz
+
+import (
+	"errors"
+	"reflect"
+)
+
+// deepCopy returns a deep copy of the struct pointed by obj.
+//
+// Pointers, slices, maps, arrays, interfaces and structs are copied recursively, so that the copy does not share
+// any memory with the original that can be modified by applying defaults. Unexported fields cannot be set with
+// reflection and they are copied shallowly. Pointers that are shared in the original are shared in the copy too.
+func deepCopy(obj interface{}) (interface{}, error) {
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("object must be a pointer to a struct")
+	}
+
+	return deepCopyValue(val, make(map[copiedPointer]reflect.Value)).Interface(), nil
+}
+
+// copiedPointer identifies a pointer in the original value.
+type copiedPointer struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// deepCopyValue returns a deep copy of the value.
+// The copied map holds the copies of the pointers that are already copied, to keep the shared pointers shared and
+// to stop at cycles.
+func deepCopyValue(src reflect.Value, copied map[copiedPointer]reflect.Value) reflect.Value {
+	dst := reflect.New(src.Type()).Elem()
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return dst
+		}
+		key := copiedPointer{typ: src.Type(), ptr: src.Pointer()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		dst = reflect.New(src.Type().Elem())
+		copied[key] = dst
+		dst.Elem().Set(deepCopyValue(src.Elem(), copied))
+
+	case reflect.Struct:
+		// copy the unexported fields shallowly, then the exported ones deeply
+		dst.Set(src)
+		for i := range src.NumField() {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopyValue(src.Field(i), copied))
+			}
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Cap()))
+		for i := range src.Len() {
+			dst.Index(i).Set(deepCopyValue(src.Index(i), copied))
+		}
+
+	case reflect.Array:
+		for i := range src.Len() {
+			dst.Index(i).Set(deepCopyValue(src.Index(i), copied))
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(deepCopyValue(iter.Key(), copied), deepCopyValue(iter.Value(), copied))
+		}
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return dst
+		}
+		dst.Set(deepCopyValue(src.Elem(), copied))
+
+	default:
+		// primitives, functions and channels are copied by value
+		dst.Set(src)
+	}
+
+	return dst
+}
@@ -5,6 +5,8 @@ import "reflect"
 // detectPotentialCycles checks if a type definition allows cycles.
 // even though a struct *instance* may not have a cycle, we check the type definition here
 // since we are creating new instances of the struct on the go in the defaultz.
+// Each instantiation of a generic type is a distinct type, so `Paged[Paged[int]]` is not a cycle while
+// `Node[T]` with a `*Node[T]` field is.
 func detectPotentialCycles(t reflect.Type, seen map[reflect.Type]bool) bool {
 	// Dereference pointer types (if any)
 	if t.Kind() == reflect.Ptr {
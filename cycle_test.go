@@ -83,6 +83,22 @@ type structWithInterface struct {
 	Any interface{}
 }
 
+// 🧬 Generic Structs.
+type genericPaged[T any] struct {
+	PageSize int
+	Items    []T
+}
+
+type genericWrapper[T any] struct {
+	genericPaged[T]
+	Other *genericPaged[string]
+}
+
+type genericNode[T any] struct {
+	Value T
+	Next  *genericNode[T]
+}
+
 // 🧩 Complex Edge Cases.
 type nestedPointerCycle struct {
 	Ref **nestedPointerCycle
@@ -117,6 +133,13 @@ func TestDetectPotentialCycles(t *testing.T) {
 		{"Struct with Map", reflect.TypeOf(structWithMap{}), false},
 		{"Struct with Interface", reflect.TypeOf(structWithInterface{}), false},
 
+		// 🧬 Generic Structs
+		{"Generic Struct", reflect.TypeOf(genericPaged[int]{}), false},
+		{"Generic Struct of Generic Struct", reflect.TypeOf(genericPaged[genericPaged[int]]{}), false},
+		{"Generic Struct Embedding Distinct Instantiations", reflect.TypeOf(genericWrapper[int]{}), false},
+		{"Generic Struct Embedding Same Instantiation", reflect.TypeOf(genericWrapper[string]{}), false},
+		{"Generic Self Cycle", reflect.TypeOf(genericNode[int]{}), true},
+
 		// 🧩 Complex Edge Cases
 		// I don't like this result, but defaulter should handle this case anyway when it is actually defaulting things
 		{"Nested Pointer Cycle", reflect.TypeOf(nestedPointerCycle{}), false},
@@ -1,11 +1,29 @@
 package defaultz
 
 import (
+	"bytes"
+	"cmp"
+	"database/sql"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // StringDefaulter is a defaulter for string fields.
@@ -37,7 +55,18 @@ func (s *StringDefaulter) HandleField(value string, _ string, _ reflect.StructFi
 	return true, true, nil
 }
 
-type BoolDefaulter struct{}
+// BoolDefaulter is a defaulter for bool fields, including the fields of named bool types such as `type Enabled bool`.
+//
+// The accepted values are "true" and "false", unless TrueTokens or FalseTokens are set.
+// To use custom tokens, register a BoolDefaulter with the tokens with a precedence lower than
+// [PrecedencePrimitiveDefaulter], or instead of the one registered by [WithBasicDefaulters].
+type BoolDefaulter struct {
+	// TrueTokens are the values that set the field to true, e.g. "yes" and "on". Defaults to "true" if empty.
+	TrueTokens []string
+
+	// FalseTokens are the values that set the field to false, e.g. "no" and "off". Defaults to "false" if empty.
+	FalseTokens []string
+}
 
 var _ Defaulter = &BoolDefaulter{}
 
@@ -51,14 +80,25 @@ func (b *BoolDefaulter) HandledKinds() []reflect.Kind {
 
 //nolint:lll
 func (b *BoolDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	trueTokens, falseTokens := b.TrueTokens, b.FalseTokens
+	if len(trueTokens) == 0 {
+		trueTokens = []string{"true"}
+	}
+	if len(falseTokens) == 0 {
+		falseTokens = []string{"false"}
+	}
+
 	var valueToSet bool
 	switch {
-	case value == "true":
+	case slices.Contains(trueTokens, value):
 		valueToSet = true
-	case value == "false":
+	case slices.Contains(falseTokens, value):
 		valueToSet = false
-	default:
+	case len(b.TrueTokens) == 0 && len(b.FalseTokens) == 0:
 		return true, false, NewError(b, ErrInvalidDefaultValue, path, field, "invalid boolean value (not 'true' nor 'false')")
+	default:
+		msg := fmt.Sprintf("invalid boolean value (not one of %q nor %q)", trueTokens, falseTokens)
+		return true, false, NewError(b, ErrInvalidDefaultValue, path, field, msg)
 	}
 
 	// Handle pointer cases
@@ -232,7 +272,57 @@ func (f *FloatDefaulter) HandleField(value string, path string, field reflect.St
 	return true, true, nil
 }
 
-type SliceDefaulter struct{}
+// DefaultItemsTag is the tag that enables an alternative syntax for the items of the default value of a slice or an
+// array field, e.g. `default:"0:a 3:d" defaultItems:"indexed"`. Without the tag, the items are taken as they are.
+const DefaultItemsTag = "defaultItems"
+
+// ItemsSyntaxIndexed is the value of the [DefaultItemsTag] for the indexed syntax, where each item is prefixed with
+// its index and a colon, e.g. `default:"0:a 3:d"`.
+const ItemsSyntaxIndexed = "indexed"
+
+// ItemsSyntaxRepeat is the value of the [DefaultItemsTag] for the repetition syntax, where an item is repeated with a
+// following `xN` item, e.g. `default:"ok x3"`.
+const ItemsSyntaxRepeat = "repeat"
+
+// maxItems is the maximum number of items that a default value of a slice can expand to, e.g. with a high index,
+// so that a typo in a tag can't allocate an enormous slice.
+const maxItems = 10000
+
+// hasItemsSyntax returns true if the [DefaultItemsTag] of the field enables the syntax.
+func hasItemsSyntax(field reflect.StructField, syntax string) bool {
+	return field.Tag.Get(DefaultItemsTag) == syntax
+}
+
+// SliceDefaulter is a defaulter for slice fields.
+// The items are separated by space, e.g. `default:"a b c"`.
+// The items are parsed with the defaulters registered for their kind, so that they are parsed like the fields of the
+// same type, e.g. `default:"1m 2h"` on a `[]time.Duration` field, or custom tokens of a [BoolDefaulter].
+//
+// Sparse slices can be defaulted with the indexed syntax, enabled with the `defaultItems:"indexed"` tag, where each
+// item is prefixed with its index and a colon, e.g. `default:"0:a 3:d"`, which yields a slice of length 4 with the
+// items at index 1 and 2 left empty. The indices must be lower than 10000. Without the tag, `default:"0:a 3:d"` yields
+// ["0:a" "3:d"].
+//
+// A default value that is a JSON array, e.g. `default:"[1,2,3]"`, is decoded with [encoding/json.Unmarshal] instead.
+// This allows items that the space-separated syntax can't express, such as structs or nested slices.
+//
+// The items of integer slices, including the ones of named integer types, can be inclusive ranges, e.g.
+// `default:"8000-8002 9000"` yields [8000 8001 8002 9000].
+//
+// With the `defaultItems:"repeat"` tag, an item can be repeated with a following `xN` item, where N is the total
+// number of times the item appears, e.g. `default:"ok x3"` yields ["ok" "ok" "ok"]. An `xN` item without a preceding
+// item is an ordinary item. Without the tag, `default:"ok x3"` yields ["ok" "x3"].
+//
+// The default value of a slice can expand to at most 10000 items, with the indices, the repetitions or the ranges.
+//
+// The items of slices of byte slices are decoded as standard base64, e.g. `default:"aGk= Ynll"` on a `[][]byte`
+// field yields ["hi" "bye"].
+type SliceDefaulter struct {
+	// registry is used to parse the items with the defaulters registered for the kind of the items, so that the
+	// items are parsed like the fields of the same type. The items are converted with the basic parsing rules if it
+	// is not set, e.g. when the defaulter is not created by [WithBasicDefaulters].
+	registry *defaulterRegistry
+}
 
 var _ Defaulter = &SliceDefaulter{}
 
@@ -246,22 +336,345 @@ func (s *SliceDefaulter) HandledKinds() []reflect.Kind {
 
 //nolint:lll
 func (s *SliceDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
-	parts := strings.Fields(value) // Split by space
+	if isJSONValue(value, '[') {
+		return unmarshalJSON(s, value, path, field, fieldValue)
+	}
+
+	parts := s.registry.splitItems(value)
+	if hasItemsSyntax(field, ItemsSyntaxIndexed) {
+		return s.handleIndexed(parts, path, field, fieldValue)
+	}
+
 	sliceType := field.Type
 	elemType := sliceType.Elem()
-	slice := reflect.MakeSlice(sliceType, len(parts), len(parts))
+	items := make([]reflect.Value, 0, len(parts))
+	repeat := hasItemsSyntax(field, ItemsSyntaxRepeat)
+	var last []reflect.Value // the values of the previous item, to be repeated
+	for _, part := range parts {
+		if count, ok := repetitionCount(part); repeat && ok && last != nil {
+			if count <= 0 {
+				msg := fmt.Sprintf("invalid repetition '%s', the count must be a positive integer", part)
+				return true, false, NewError(s, ErrInvalidDefaultValueItem, path, field, msg)
+			}
+			if count > maxItems || len(items)+len(last)*(count-1) > maxItems {
+				msg := fmt.Sprintf("invalid repetition '%s', the slice can't have more than %d items", part, maxItems)
+				return true, false, NewError(s, ErrInvalidDefaultValueItem, path, field, msg)
+			}
+			// the previous item is already in the items once
+			for range count - 1 {
+				items = append(items, last...)
+			}
+			last = nil
+			continue
+		}
+
+		expanded, ok, err := expandRange(part, elemType, maxItems-len(items))
+		if errors.Is(err, errTooManyItems) {
+			return true, false, NewError(s, ErrInvalidDefaultValue, path, field, err.Error())
+		}
+		if err != nil {
+			return true, false, NewError(s, ErrInvalidDefaultValueItem, path, field, err.Error())
+		}
+		if ok {
+			items = append(items, expanded...)
+			last = expanded
+			continue
+		}
+
+		v, err := s.convertItem(part, path, field)
+		if err != nil {
+			return true, false, NewError(s, ErrInvalidDefaultValueItem, path, field, err.Error())
+		}
+		items = append(items, v)
+		last = []reflect.Value{v}
+	}
+
+	slice := reflect.MakeSlice(sliceType, len(items), len(items))
+	for j, item := range items {
+		slice.Index(j).Set(item)
+	}
+	fieldValue.Set(slice)
+	return true, true, nil
+}
+
+// convertItem converts an item of the slice with the defaulters registered for the kind of the items, e.g. the
+// [DurationDefaulter] for `[]time.Duration`. The items of types implementing [encoding.TextUnmarshaler], and the items
+// of kinds without defaulters, are converted with the basic parsing rules.
+// The items of byte slices, e.g. in `[][]byte`, are decoded as standard base64.
+func (s *SliceDefaulter) convertItem(item string, path string, field reflect.StructField) (reflect.Value, error) {
+	elemType := field.Type.Elem()
+	if elemType.Kind() == reflect.Slice && elemType.Elem().Kind() == reflect.Uint8 {
+		decoded, err := base64.StdEncoding.DecodeString(item)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot decode '%s' as base64: %w", item, err)
+		}
+		return reflect.ValueOf(decoded).Convert(elemType), nil
+	}
+	if s.registry == nil || reflect.PointerTo(elemType).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return convertItemValue(item, elemType)
+	}
+	defaulters := s.registry.defaulters[elemType.Kind()]
+	if len(defaulters) == 0 {
+		return convertItemValue(item, elemType)
+	}
+
+	// the defaulters set the item as if it was a field of the item type, with the tags of the slice field
+	elem := reflect.New(elemType).Elem()
+	elemField := reflect.StructField{Name: field.Name, Type: elemType, Tag: field.Tag}
+	var msgs []string
+	for _, defaulterWithPrecedence := range defaulters {
+		callNext, set, err := defaulterWithPrecedence.Defaulter.HandleField(item, path, elemField, elem)
+		if err != nil {
+			var defaultzErr *Error
+			if errors.As(err, &defaultzErr) {
+				msgs = append(msgs, defaultzErr.Msg)
+			} else {
+				msgs = append(msgs, err.Error())
+			}
+		}
+		if set {
+			return elem, nil
+		}
+		if !callNext {
+			break
+		}
+	}
+	if len(msgs) == 0 {
+		return convertItemValue(item, elemType)
+	}
+	return reflect.Value{}, errors.New(strings.Join(msgs, "; "))
+}
+
+// repetitionCount returns the count of a repetition item, e.g. 3 for `x3`, and true if the item is a repetition.
+// The count is not validated, so that the invalid counts such as `x0` or `x-1` can be reported.
+func repetitionCount(item string) (int, bool) {
+	count, ok := strings.CutPrefix(item, "x")
+	digits := strings.TrimLeft(count, "+-")
+	if !ok || digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		// out of range
+		return 0, true
+	}
+	return n, true
+}
+
+// errTooManyItems is returned by expandRange for the ranges with more items than the limit.
+//
+//nolint:gochecknoglobals	// an unexported sentinel to tell the ranges that are too large from the invalid ones
+var errTooManyItems = errors.New("too many items")
+
+// expandRange expands an inclusive range of integers, e.g. `8000-8002`, into the values of the element type, which
+// can be a named integer type such as `type Port uint16`. It returns false if the item is not a range of integers or
+// the element type is not an integer type, so that the item is converted as is.
+// It returns an error wrapping errTooManyItems if the range has more items than the limit.
+func expandRange(item string, elemType reflect.Type, limit int) ([]reflect.Value, bool, error) {
+	// the start can be negative, so the separator is the first dash after the first character
+	i := strings.Index(item[min(1, len(item)):], "-") + 1
+	if i <= 0 || reflect.PointerTo(elemType).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return nil, false, nil
+	}
+	from, to := item[:i], item[i+1:]
+
+	var values []reflect.Value
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch elemType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		start, err1 := strconv.ParseInt(from, 10, 64)
+		end, err2 := strconv.ParseInt(to, 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, false, nil
+		}
+		zero := reflect.Zero(elemType)
+		if start > end || zero.OverflowInt(start) || zero.OverflowInt(end) {
+			return nil, false, fmt.Errorf("invalid range '%s' for %s", item, elemType)
+		}
+		// the difference can't overflow as unsigned, even for the whole int64 range
+		//nolint:gosec	// the conversions wrap around on purpose, start <= end
+		if err := checkRangeSize(item, uint64(end)-uint64(start), limit); err != nil {
+			return nil, false, err
+		}
+		for n := start; n <= end && n >= start; n++ {
+			values = append(values, reflect.ValueOf(n).Convert(elemType))
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		start, err1 := strconv.ParseUint(from, 10, 64)
+		end, err2 := strconv.ParseUint(to, 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, false, nil
+		}
+		zero := reflect.Zero(elemType)
+		if start > end || zero.OverflowUint(start) || zero.OverflowUint(end) {
+			return nil, false, fmt.Errorf("invalid range '%s' for %s", item, elemType)
+		}
+		if err := checkRangeSize(item, end-start, limit); err != nil {
+			return nil, false, err
+		}
+		for n := start; n <= end && n >= start; n++ {
+			values = append(values, reflect.ValueOf(n).Convert(elemType))
+		}
+
+	default:
+		return nil, false, nil
+	}
+
+	return values, true, nil
+}
+
+// checkRangeSize returns an error wrapping errTooManyItems if a range with the difference between its start and end
+// has more items than the limit.
+func checkRangeSize(item string, diff uint64, limit int) error {
+	if limit <= 0 || diff >= uint64(limit) {
+		return fmt.Errorf("%w: the range '%s' makes the slice longer than %d items", errTooManyItems, item, maxItems)
+	}
+	return nil
+}
+
+//nolint:lll
+func (s *SliceDefaulter) handleIndexed(parts []string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	indices := make([]int, len(parts))
+	items := make([]string, len(parts))
+	length := 0
 	for j, part := range parts {
-		v, err := convertValue(part, elemType)
+		index, item, ok := strings.Cut(part, ":")
+		if !ok {
+			return true, false, NewError(s, ErrInvalidDefaultValueKey, path, field,
+				fmt.Sprintf("invalid indexed item '%s', expected <index>:<value>", part))
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= maxItems {
+			return true, false, NewError(s, ErrInvalidDefaultValueKey, path, field,
+				fmt.Sprintf("invalid index '%s', must be a non-negative integer lower than %d", index, maxItems))
+		}
+		indices[j] = i
+		items[j] = item
+		length = max(length, i+1)
+	}
+
+	slice := reflect.MakeSlice(field.Type, length, length)
+	for j, item := range items {
+		v, err := s.convertItem(item, path, field)
 		if err != nil {
 			return true, false, NewError(s, ErrInvalidDefaultValueItem, path, field, err.Error())
 		}
-		slice.Index(j).Set(v)
+		slice.Index(indices[j]).Set(v)
 	}
 	fieldValue.Set(slice)
 	return true, true, nil
 }
 
-type MapDefaulter struct{}
+// ArrayDefaulter is a defaulter for fixed-size array fields.
+// The items are separated by space and set from the first element on, e.g. `default:"a b"` on a `[3]string` yields
+// ["a" "b" ""].
+//
+// Elements can be set individually with the indexed syntax, enabled with the `defaultItems:"indexed"` tag like for the
+// [SliceDefaulter], where each item is prefixed with its index and a colon, e.g. `default:"0:a 2:c"` yields
+// ["a" "" "c"]. Without the tag, `default:"8080:80 9090:90"` on a `[2]string` yields ["8080:80" "9090:90"].
+// It is an error to give more items than the array length, or an index that is out of its bounds.
+//
+// To set byte arrays from hexadecimal numbers, register the [ByteArrayDefaulter] with a lower precedence.
+type ArrayDefaulter struct{}
+
+var _ Defaulter = &ArrayDefaulter{}
+
+func (a *ArrayDefaulter) Name() string {
+	return "defaultz.ArrayDefaulter"
+}
+
+func (a *ArrayDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Array}
+}
+
+//nolint:lll
+func (a *ArrayDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	arrayType := field.Type
+	if arrayType.Kind() == reflect.Ptr {
+		arrayType = arrayType.Elem()
+	}
+
+	parts := strings.Fields(value) // Split by space
+
+	indices := make([]int, len(parts))
+	items := make([]string, len(parts))
+	if hasItemsSyntax(field, ItemsSyntaxIndexed) {
+		for j, part := range parts {
+			index, item, ok := strings.Cut(part, ":")
+			if !ok {
+				msg := fmt.Sprintf("invalid indexed item '%s', expected <index>:<value>", part)
+				return true, false, NewError(a, ErrInvalidDefaultValueKey, path, field, msg)
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 || i >= arrayType.Len() {
+				msg := fmt.Sprintf("invalid index '%s', must be between 0 and %d", index, arrayType.Len()-1)
+				return true, false, NewError(a, ErrInvalidDefaultValueKey, path, field, msg)
+			}
+			indices[j] = i
+			items[j] = item
+		}
+	} else {
+		if len(parts) > arrayType.Len() {
+			msg := fmt.Sprintf("%d items given, but the array has %d", len(parts), arrayType.Len())
+			return true, false, NewError(a, ErrInvalidDefaultValueItem, path, field, msg)
+		}
+		for j, part := range parts {
+			indices[j] = j
+			items[j] = part
+		}
+	}
+
+	array := reflect.New(arrayType).Elem()
+	for j, item := range items {
+		v, err := convertItemValue(item, arrayType.Elem())
+		if err != nil {
+			return true, false, NewError(a, ErrInvalidDefaultValueItem, path, field, err.Error())
+		}
+		array.Index(indices[j]).Set(v)
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(arrayType)) // Allocate new array pointer
+		}
+		fieldValue.Elem().Set(array) // Set the actual array value
+	} else {
+		fieldValue.Set(array) // Direct array assignment
+	}
+
+	return true, true, nil
+}
+
+// MapDefaulter is a defaulter for map fields.
+// The pairs are separated by space and the key and value of a pair are separated by the first colon,
+// e.g. `default:"a:1 b:2"`.
+//
+// If a key is given multiple times, the later pair overrides the earlier ones: `default:"a:1 a:2"` yields a:2.
+// The exception is the maps with slice values, such as [net/url.Values], where the values of a repeated key are
+// accumulated in the slice: `default:"a:1 a:2 b:3"` yields a:[1 2] and b:[3].
+//
+// A default value that is a JSON object, e.g. `default:"{\"a\":1}"`, is decoded with [encoding/json.Unmarshal] instead.
+//
+// The separators can be configured for the keys or the values containing spaces or colons, e.g. with the
+// PairSeparator ";" and the KeyValueSeparator "=", `default:"url=http://x:8080;name=a b"` yields
+// url:"http://x:8080" and name:"a b". Register such a MapDefaulter with a precedence lower than
+// [PrecedencePrimitiveDefaulter]. It handles the maps entirely, so that the one registered by [WithBasicDefaulters]
+// doesn't parse them again with its separators.
+type MapDefaulter struct {
+	// PairSeparator separates the pairs, e.g. ";". The pairs are trimmed, a trailing separator is tolerated and the
+	// separator can be escaped with a backslash. If not set, the pairs are separated by whitespace, or by the
+	// separator set with [WithCollectionSeparator].
+	PairSeparator string
+
+	// KeyValueSeparator separates the key and the value of a pair, at its first occurrence, e.g. "=".
+	// ":" is used if not set.
+	KeyValueSeparator string
+
+	// registry is used to split the pairs with the separator set with [WithCollectionSeparator], if any.
+	registry *defaulterRegistry
+}
 
 var _ Defaulter = &MapDefaulter{}
 
@@ -275,26 +688,57 @@ func (m *MapDefaulter) HandledKinds() []reflect.Kind {
 
 //nolint:lll
 func (m *MapDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	if isJSONValue(value, '{') {
+		return unmarshalJSON(m, value, path, field, fieldValue)
+	}
+
+	// the maps are not parsed again by the MapDefaulter of the basic defaulters, with the default separators
+	callNext := m.PairSeparator == "" && m.KeyValueSeparator == ""
+
 	mapInstance := reflect.MakeMap(field.Type)
-	pairs := strings.Fields(value) // Split by space
+	var pairs []string
+	if m.PairSeparator != "" {
+		pairs = splitTrimmed(value, m.PairSeparator)
+	} else {
+		pairs = m.registry.splitItems(value)
+	}
+	kvSeparator := ":"
+	if m.KeyValueSeparator != "" {
+		kvSeparator = m.KeyValueSeparator
+	}
 
 	for _, pair := range pairs {
 		//nolint:mnd	// well... pairs have 2 parts
-		kv := strings.SplitN(pair, ":", 2)
+		kv := strings.SplitN(pair, kvSeparator, 2)
 		//nolint:mnd	// well... pairs have 2 parts
 		if len(kv) == 2 {
 			// Convert the key to the appropriate type
 			keyType := field.Type.Key() // The map's key type
-			key, err := convertValue(kv[0], keyType)
+			key, err := convertItemValue(kv[0], keyType)
 			if err != nil {
-				return true, false, NewError(m, ErrInvalidDefaultValueKey, path, field, err.Error())
+				return callNext, false, NewError(m, ErrInvalidDefaultValueKey, path, field, err.Error())
 			}
 
 			// Convert the value to the appropriate type
 			valueType := field.Type.Elem() // The map's value type
-			value, err := convertValue(kv[1], valueType)
+			if valueType.Kind() == reflect.Slice {
+				// Accumulate the values of repeated keys, e.g. for url.Values
+				item, err := convertItemValue(kv[1], valueType.Elem())
+				if err != nil {
+					return callNext, false, NewError(m, ErrInvalidDefaultValueItem, path, field, err.Error())
+				}
+
+				items := mapInstance.MapIndex(key)
+				if !items.IsValid() {
+					items = reflect.MakeSlice(valueType, 0, 1)
+				}
+				mapInstance.SetMapIndex(key, reflect.Append(items, item))
+				continue
+			}
+
+			value, err := convertItemValue(kv[1], valueType)
 			if err != nil {
-				return true, false, NewError(m, ErrInvalidDefaultValueItem, path, field, err.Error())
+				return callNext, false, NewError(m, ErrInvalidDefaultValueItem, path, field, err.Error())
 			}
 
 			// Set the key-value pair in the map
@@ -302,9 +746,29 @@ func (m *MapDefaulter) HandleField(value string, path string, field reflect.Stru
 		}
 	}
 	fieldValue.Set(mapInstance)
+	return callNext, true, nil
+}
+
+// isJSONValue returns true if the value starts with the given delimiter and is valid JSON.
+// Values that are not valid JSON are left to the space-separated syntax.
+func isJSONValue(value string, delim byte) bool {
+	value = strings.TrimSpace(value)
+	return len(value) > 0 && value[0] == delim && json.Valid([]byte(value))
+}
+
+//nolint:lll
+func unmarshalJSON(d Defaulter, value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	v := reflect.New(field.Type)
+	if err := json.Unmarshal([]byte(value), v.Interface()); err != nil {
+		return true, false, NewError(d, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid JSON value: %v", err))
+	}
+	fieldValue.Set(v.Elem())
 	return true, true, nil
 }
 
+// DurationDefaulter is a defaulter for [time.Duration] fields.
+// The default values are parsed with [time.ParseDuration], extended with the units "d" for days of 24 hours and
+// "w" for weeks of 7 days, e.g. `default:"7d"` or `default:"1d12h"`.
 type DurationDefaulter struct{}
 
 var _ Defaulter = &DurationDefaulter{}
@@ -319,7 +783,7 @@ func (d *DurationDefaulter) HandledKinds() []reflect.Kind {
 
 //nolint:lll
 func (d *DurationDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
-	duration, err := time.ParseDuration(value)
+	duration, err := parseDuration(value)
 	if err != nil {
 		return true, false, NewError(d, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid duration value: %v", err))
 	}
@@ -337,6 +801,1658 @@ func (d *DurationDefaulter) HandleField(value string, path string, field reflect
 	return true, true, nil
 }
 
+// ByteArrayDefaulter is a defaulter for fixed-size byte array fields, such as `[4]byte`, that hold an encoded number.
+// The default value is a hexadecimal number with an optional "0x" prefix, e.g. `default:"0x0A0B0C0D"`.
+//
+// The number is written big-endian, unless LittleEndian is set. The byte order can also be chosen per field with
+// the `endian` tag, which is either "big" or "little" and takes precedence over LittleEndian.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly, with a precedence
+// lower than [PrecedencePrimitiveDefaulter] so that it runs before the [ArrayDefaulter]. The next defaulters are not
+// called for the byte arrays. The other arrays are passed to the next defaulters.
+type ByteArrayDefaulter struct {
+	// LittleEndian makes the defaulter write the number little-endian.
+	LittleEndian bool
+}
+
+var _ Defaulter = &ByteArrayDefaulter{}
+
+func (b *ByteArrayDefaulter) Name() string {
+	return "defaultz.ByteArrayDefaulter"
+}
+
+func (b *ByteArrayDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Array}
+}
+
+//nolint:lll
+func (b *ByteArrayDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	arrayType := field.Type
+	if arrayType.Kind() == reflect.Ptr {
+		arrayType = arrayType.Elem()
+	}
+	if arrayType.Elem().Kind() != reflect.Uint8 {
+		return true, false, NewError(b, ErrNotSupported, path, field, "only byte arrays are supported")
+	}
+
+	littleEndian := b.LittleEndian
+	switch endian := field.Tag.Get("endian"); endian {
+	case "":
+	case "big":
+		littleEndian = false
+	case "little":
+		littleEndian = true
+	default:
+		return false, false, NewError(b, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid endian '%s' (not 'big' nor 'little')", endian))
+	}
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	decoded, err := hex.DecodeString(digits)
+	if err != nil {
+		return false, false, NewError(b, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+	// leading zero bytes don't change the number, so they don't count against the array length
+	for len(decoded) > arrayType.Len() && decoded[0] == 0 {
+		decoded = decoded[1:]
+	}
+	if len(decoded) > arrayType.Len() {
+		return false, false, NewError(b, ErrInvalidDefaultValue, path, field, fmt.Sprintf("value needs %d bytes, but the array has %d", len(decoded), arrayType.Len()))
+	}
+
+	array := reflect.New(arrayType).Elem()
+	offset := arrayType.Len() - len(decoded)
+	for j, by := range decoded {
+		index := offset + j
+		if littleEndian {
+			index = len(decoded) - 1 - j
+		}
+		array.Index(index).SetUint(uint64(by))
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(arrayType)) // Allocate new array pointer
+		}
+		fieldValue.Elem().Set(array) // Set the actual array value
+	} else {
+		fieldValue.Set(array) // Direct array assignment
+	}
+
+	return false, true, nil
+}
+
+// EnumDefaulter is a defaulter for named integer types used as enums, which sets the values by their names.
+// It handles fields of the registered types, pointers to them and slices of them.
+//
+// For example, after adding `map[string]Weekday{"Monday": Monday, "Friday": Friday}`:
+//
+//   - `default:"Monday"` on a Weekday field yields Monday
+//   - `default:"Monday Friday"` on a []Weekday field yields []Weekday{Monday, Friday}
+//
+// Numbers are accepted as well, so `default:"1"` still works.
+//
+// See [ExtendedDefaulterRegistry.RegisterEnum] for registering the names.
+type EnumDefaulter struct {
+	names map[reflect.Type]map[string]reflect.Value
+}
+
+var _ Defaulter = &EnumDefaulter{}
+
+// NewEnumDefaulter creates an EnumDefaulter without any names.
+func NewEnumDefaulter() *EnumDefaulter {
+	return &EnumDefaulter{
+		names: make(map[reflect.Type]map[string]reflect.Value),
+	}
+}
+
+// Add adds the names of an enum type. The names must be a map with string keys and the enum type as the value type,
+// e.g. `map[string]Weekday{"Monday": Monday}`.
+//
+// It panics if the names are not such a map.
+func (e *EnumDefaulter) Add(names interface{}) *EnumDefaulter {
+	namesValue := reflect.ValueOf(names)
+	if namesValue.Kind() != reflect.Map || namesValue.Type().Key().Kind() != reflect.String {
+		panic(fmt.Sprintf("enum names must be a map with string keys, got %T", names))
+	}
+
+	enumType := namesValue.Type().Elem()
+	if e.names[enumType] == nil {
+		e.names[enumType] = make(map[string]reflect.Value, namesValue.Len())
+	}
+	for iter := namesValue.MapRange(); iter.Next(); {
+		e.names[enumType][iter.Key().String()] = iter.Value()
+	}
+	return e
+}
+
+func (e *EnumDefaulter) Name() string {
+	return "defaultz.EnumDefaulter"
+}
+
+func (e *EnumDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Slice,
+	}
+}
+
+//nolint:lll
+func (e *EnumDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if fieldType.Kind() == reflect.Slice {
+		if _, ok := e.names[fieldType.Elem()]; !ok {
+			return true, false, nil
+		}
+
+		// we handle the slices of the enum types entirely, no need to call the next defaulters
+		parts := strings.Fields(value) // Split by space
+		slice := reflect.MakeSlice(fieldType, len(parts), len(parts))
+		for j, part := range parts {
+			v, err := e.lookup(part, fieldType.Elem())
+			if err != nil {
+				return false, false, NewError(e, ErrInvalidDefaultValueItem, path, field, err.Error())
+			}
+			slice.Index(j).Set(v)
+		}
+		fieldValue.Set(slice)
+		return false, true, nil
+	}
+
+	if _, ok := e.names[fieldType]; !ok {
+		return true, false, nil
+	}
+
+	// we handle the enum types entirely, no need to call the next defaulters
+	v, err := e.lookup(value, fieldType)
+	if err != nil {
+		return false, false, NewError(e, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new enum pointer
+		}
+		fieldValue.Elem().Set(v) // Set the actual enum value
+	} else {
+		fieldValue.Set(v) // Direct enum assignment
+	}
+	return false, true, nil
+}
+
+func (e *EnumDefaulter) lookup(name string, enumType reflect.Type) (reflect.Value, error) {
+	if v, ok := e.names[enumType][name]; ok {
+		return v, nil
+	}
+	if v, err := convertValue(name, enumType); err == nil {
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unknown name '%s' for %s", name, enumType)
+}
+
+// ISODurationDefaulter is a defaulter for [time.Duration] fields with default values in the ISO 8601 duration format,
+// e.g. `default:"PT1H30M"`, `default:"P1DT12H"`, `default:"P2W"` or `default:"-PT0.5S"`.
+// Years and months are not supported, as their lengths vary. A day is 24 hours and a week is 7 days.
+//
+// The values that don't start with "P", optionally after a sign, are passed to the next defaulter, so it can be
+// registered with any precedence next to the [DurationDefaulter].
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly.
+type ISODurationDefaulter struct{}
+
+var _ Defaulter = &ISODurationDefaulter{}
+
+func (d *ISODurationDefaulter) Name() string {
+	return "defaultz.ISODurationDefaulter"
+}
+
+func (d *ISODurationDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Int64}
+}
+
+//nolint:lll
+func (d *ISODurationDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	durationType := field.Type
+	if durationType.Kind() == reflect.Ptr {
+		durationType = durationType.Elem()
+	}
+	if durationType != reflect.TypeFor[time.Duration]() || !strings.HasPrefix(strings.TrimLeft(value, "+-"), "P") {
+		return true, false, nil
+	}
+
+	duration, err := parseISODuration(value)
+	if err != nil {
+		return true, false, NewError(d, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(durationType)) // Allocate new duration pointer
+		}
+		fieldValue.Elem().SetInt(int64(duration)) // Set the actual duration value
+	} else {
+		fieldValue.SetInt(int64(duration)) // Direct duration assignment
+	}
+
+	return false, true, nil
+}
+
+// parseISODuration parses an ISO 8601 duration without years and months, e.g. "P1DT1H30M", by converting it to the
+// format of parseDuration, e.g. "1d1h30m".
+func parseISODuration(value string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid ISO 8601 duration '%s'", value)
+
+	var sb strings.Builder
+	rest := value
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		sb.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+	rest, ok := strings.CutPrefix(rest, "P")
+	if !ok || rest == "" {
+		return 0, invalid
+	}
+
+	date, clock, hasClock := strings.Cut(rest, "T")
+	if hasClock && clock == "" {
+		return 0, invalid
+	}
+	for _, part := range []struct{ components, units, goUnits string }{
+		{components: date, units: "WD", goUnits: "wd"},
+		{components: clock, units: "HMS", goUnits: "hms"},
+	} {
+		unitIndex := 0 // the units must be in order, without repetition
+		for part.components != "" {
+			end := strings.IndexFunc(part.components, func(r rune) bool {
+				return (r < '0' || r > '9') && r != '.' && r != ','
+			})
+			if end <= 0 {
+				return 0, invalid
+			}
+			i := strings.IndexByte(part.units[unitIndex:], part.components[end])
+			if i < 0 {
+				if strings.IndexByte("YM", part.components[end]) >= 0 && part.units == "WD" {
+					return 0, fmt.Errorf("invalid ISO 8601 duration '%s', years and months are not supported", value)
+				}
+				return 0, invalid
+			}
+			unitIndex += i
+			sb.WriteString(strings.ReplaceAll(part.components[:end], ",", "."))
+			sb.WriteByte(part.goUnits[unitIndex])
+			unitIndex++
+			part.components = part.components[end+1:]
+		}
+	}
+
+	duration, err := parseDuration(sb.String())
+	if err != nil {
+		return 0, invalid
+	}
+	return duration, nil
+}
+
+// parseDuration parses a duration like [time.ParseDuration], with the additional units "d" for 24 hours and "w" for
+// 7 days. The days and weeks are converted to hours before parsing, e.g. "1d12h" is parsed as "24h12h".
+func parseDuration(value string) (time.Duration, error) {
+	if !strings.ContainsAny(value, "dw") {
+		return time.ParseDuration(value)
+	}
+
+	const hoursPerDay = 24
+	const hoursPerWeek = 7 * hoursPerDay
+
+	var sb strings.Builder
+	start := 0 // start of the number before the current unit
+	for i := range len(value) {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9' || c == '.':
+			continue
+		case c == 'd' || c == 'w':
+			n, err := strconv.ParseFloat(value[start:i], 64)
+			if err != nil {
+				return 0, fmt.Errorf("time: invalid duration %q", value)
+			}
+			hours := float64(hoursPerDay)
+			if c == 'w' {
+				hours = float64(hoursPerWeek)
+			}
+			sb.WriteString(strconv.FormatFloat(n*hours, 'f', -1, 64))
+			sb.WriteString("h")
+		default:
+			sb.WriteString(value[start : i+1])
+		}
+		start = i + 1
+	}
+	sb.WriteString(value[start:])
+
+	duration, err := time.ParseDuration(sb.String())
+	if err != nil {
+		return 0, fmt.Errorf("time: invalid duration %q", value)
+	}
+	return duration, nil
+}
+
+// DurationUnitDefaulter is a defaulter for named integer types that hold durations in a unit, e.g.
+// `type Millis int64` for milliseconds. The default values are parsed like the ones of [DurationDefaulter] and
+// converted to the unit of the type. Plain numbers are taken as they are, in the unit of the type.
+//
+// For example, after adding `Millis` with the unit [time.Millisecond]:
+//
+//   - `default:"2s"` on a Millis field yields 2000
+//   - `default:"500"` on a Millis field yields 500
+//   - `default:"read:500ms write:1s"` on a map[string]Millis field yields read:500 and write:1000
+//
+// It handles fields of the added types, pointers to them, and slices and maps of them.
+// Durations that are not a whole number of the unit, e.g. "1500us" for Millis, are rejected.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly, with a precedence
+// lower than [PrecedencePrimitiveDefaulter] so that it runs before the defaulters of the underlying kinds.
+type DurationUnitDefaulter struct {
+	units map[reflect.Type]time.Duration
+}
+
+var _ Defaulter = &DurationUnitDefaulter{}
+
+// NewDurationUnitDefaulter creates a DurationUnitDefaulter without any types.
+func NewDurationUnitDefaulter() *DurationUnitDefaulter {
+	return &DurationUnitDefaulter{
+		units: make(map[reflect.Type]time.Duration),
+	}
+}
+
+// Add adds a named integer type with its unit. The type is given as a value of it, e.g. `Millis(0)`.
+//
+// It panics if the type is not a signed integer type or if the unit is not positive.
+func (d *DurationUnitDefaulter) Add(sample interface{}, unit time.Duration) *DurationUnitDefaulter {
+	sampleType := reflect.TypeOf(sample)
+	if sampleType == nil || sampleType.Kind() < reflect.Int || sampleType.Kind() > reflect.Int64 {
+		panic(fmt.Sprintf("duration unit types must be signed integer types, got %T", sample))
+	}
+	if unit <= 0 {
+		panic(fmt.Sprintf("duration unit must be positive, got %s", unit))
+	}
+
+	if d.units == nil {
+		d.units = make(map[reflect.Type]time.Duration)
+	}
+	d.units[sampleType] = unit
+	return d
+}
+
+func (d *DurationUnitDefaulter) Name() string {
+	return "defaultz.DurationUnitDefaulter"
+}
+
+func (d *DurationUnitDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Slice,
+		reflect.Map,
+	}
+}
+
+//nolint:lll
+func (d *DurationUnitDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	// we handle the fields of the added types entirely, no need to call the next defaulters
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch fieldType.Kind() {
+	case reflect.Slice:
+		if _, ok := d.units[fieldType.Elem()]; !ok {
+			return true, false, nil
+		}
+
+		parts := strings.Fields(value) // Split by space
+		slice := reflect.MakeSlice(fieldType, len(parts), len(parts))
+		for j, part := range parts {
+			v, err := d.convert(part, fieldType.Elem())
+			if err != nil {
+				return false, false, NewError(d, ErrInvalidDefaultValueItem, path, field, err.Error())
+			}
+			slice.Index(j).Set(v)
+		}
+		fieldValue.Set(slice)
+		return false, true, nil
+
+	case reflect.Map:
+		_, keyOk := d.units[fieldType.Key()]
+		_, valueOk := d.units[fieldType.Elem()]
+		if !keyOk && !valueOk {
+			return true, false, nil
+		}
+
+		mapInstance := reflect.MakeMap(fieldType)
+		for _, pair := range strings.Fields(value) {
+			//nolint:mnd	// well... pairs have 2 parts
+			kv := strings.SplitN(pair, ":", 2)
+			//nolint:mnd	// well... pairs have 2 parts
+			if len(kv) != 2 {
+				continue
+			}
+			key, err := d.convert(kv[0], fieldType.Key())
+			if err != nil {
+				return false, false, NewError(d, ErrInvalidDefaultValueKey, path, field, err.Error())
+			}
+			item, err := d.convert(kv[1], fieldType.Elem())
+			if err != nil {
+				return false, false, NewError(d, ErrInvalidDefaultValueItem, path, field, err.Error())
+			}
+			mapInstance.SetMapIndex(key, item)
+		}
+		fieldValue.Set(mapInstance)
+		return false, true, nil
+
+	default:
+		if _, ok := d.units[fieldType]; !ok {
+			return true, false, nil
+		}
+
+		v, err := d.convert(value, fieldType)
+		if err != nil {
+			return false, false, NewError(d, ErrInvalidDefaultValue, path, field, err.Error())
+		}
+
+		// Handle pointer cases
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldType)) // Allocate new pointer
+			}
+			fieldValue.Elem().Set(v) // Set the actual value
+		} else {
+			fieldValue.Set(v) // Direct assignment
+		}
+		return false, true, nil
+	}
+}
+
+// convert converts the value to the type, in the unit of the type if the type is added.
+func (d *DurationUnitDefaulter) convert(value string, t reflect.Type) (reflect.Value, error) {
+	unit, ok := d.units[t]
+	if !ok {
+		return convertValue(value, t)
+	}
+
+	if number, err := strconv.ParseInt(value, 10, t.Bits()); err == nil {
+		return reflect.ValueOf(number).Convert(t), nil
+	}
+
+	duration, err := parseDuration(value)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid duration value: %w", err)
+	}
+	if duration%unit != 0 {
+		return reflect.Value{}, fmt.Errorf("duration %s is not a whole number of %s", duration, unit)
+	}
+
+	number := reflect.ValueOf(int64(duration / unit)).Convert(t)
+	if number.Int() != int64(duration/unit) {
+		return reflect.Value{}, fmt.Errorf("duration %s overflows %s", duration, t)
+	}
+	return number, nil
+}
+
+// BufferDefaulter is a defaulter for [bytes.Buffer] and *[bytes.Buffer] fields, which initializes a fresh buffer with
+// the default value, e.g. `default:"hello"`. The fields of other struct types are passed to the next defaulter.
+type BufferDefaulter struct{}
+
+var _ Defaulter = &BufferDefaulter{}
+
+func (b *BufferDefaulter) Name() string {
+	return "defaultz.BufferDefaulter"
+}
+
+func (b *BufferDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Struct}
+}
+
+//nolint:lll
+func (b *BufferDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	bufferType := field.Type
+	if bufferType.Kind() == reflect.Ptr {
+		bufferType = bufferType.Elem()
+	}
+	if bufferType != reflect.TypeFor[bytes.Buffer]() {
+		return true, false, nil
+	}
+
+	buffer := bytes.NewBufferString(value)
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(reflect.ValueOf(buffer)) // Set a fresh buffer pointer
+	} else {
+		fieldValue.Set(reflect.ValueOf(buffer).Elem()) // Direct buffer assignment
+	}
+
+	return false, true, nil
+}
+
+// NetIPDefaulter is a defaulter for [netip.Addr] and [netip.Prefix] fields, and pointers to them.
+// The default values are parsed with [netip.ParseAddr] and [netip.ParsePrefix], e.g. `default:"192.168.0.1"`,
+// `default:"::1"` or `default:"10.0.0.0/8"`. The fields of other struct types are passed to the next defaulter.
+type NetIPDefaulter struct{}
+
+var _ Defaulter = &NetIPDefaulter{}
+
+func (n *NetIPDefaulter) Name() string {
+	return "defaultz.NetIPDefaulter"
+}
+
+func (n *NetIPDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Struct}
+}
+
+//nolint:lll
+func (n *NetIPDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	ipType := field.Type
+	if ipType.Kind() == reflect.Ptr {
+		ipType = ipType.Elem()
+	}
+
+	var parsed any
+	var err error
+	switch ipType {
+	case reflect.TypeFor[netip.Addr]():
+		parsed, err = netip.ParseAddr(value)
+	case reflect.TypeFor[netip.Prefix]():
+		parsed, err = netip.ParsePrefix(value)
+	default:
+		return true, false, nil
+	}
+	if err != nil {
+		return true, false, NewError(n, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(ipType)) // Allocate new address pointer
+		}
+		fieldValue.Elem().Set(reflect.ValueOf(parsed)) // Set the actual address value
+	} else {
+		fieldValue.Set(reflect.ValueOf(parsed)) // Direct address assignment
+	}
+
+	return false, true, nil
+}
+
+// StructLiteralDefaulter is a defaulter for struct fields, which sets the fields of the struct from `key=value` pairs
+// in the default value. The keys match the names of the exported fields case-insensitively and the values are set
+// with the defaulters registered for the kinds of the fields.
+//
+// For example, `default:"max=3,backoff=1s"` on a `Retry struct{ Max int; Backoff time.Duration }` field yields
+// `Retry{Max: 3, Backoff: time.Second}`. The pairs can be separated by commas or spaces; with an extractor using the
+// comma as the separator, use spaces: `default:"max=3 backoff=1s"`.
+//
+// The fields that are not in the default value keep their zero values and default values without a `=` are passed
+// to the next defaulter. The struct is only set if all the pairs are applied.
+//
+// It is registered with [WithStructLiterals], which is not included in [WithBasicDefaulters].
+type StructLiteralDefaulter struct {
+	registry *defaulterRegistry
+}
+
+var _ Defaulter = &StructLiteralDefaulter{}
+
+func (s *StructLiteralDefaulter) Name() string {
+	return "defaultz.StructLiteralDefaulter"
+}
+
+func (s *StructLiteralDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Struct}
+}
+
+//nolint:lll
+func (s *StructLiteralDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	if !strings.Contains(value, "=") {
+		return true, false, nil
+	}
+
+	structType := field.Type
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	// set the fields on a copy, so that the field is left untouched if a pair cannot be applied
+	target := reflect.New(structType).Elem()
+	structPath := addFieldToPath(path, field)
+	pairs := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, pair := range pairs {
+		key, item, ok := strings.Cut(pair, "=")
+		if !ok {
+			return false, false, NewError(s, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid pair '%s', expected key=value", pair))
+		}
+
+		structField, ok := findFieldFold(structType, key)
+		if !ok {
+			msg := fmt.Sprintf("unknown field '%s' for %s", key, structType)
+			return false, false, NewError(s, ErrInvalidDefaultValueKey, path, field, msg)
+		}
+
+		kind := structField.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = structField.Type.Elem().Kind()
+		}
+		defaulters, ok := s.registry.defaulters[kind]
+		if !ok {
+			return false, false, NewError(s, ErrNotSupported, structPath, structField, fmt.Sprintf("no defaulters found for kind '%s'", kind))
+		}
+		if _, err := callDefaulters(defaulters, item, structPath, structField, target.FieldByIndex(structField.Index)); err != nil {
+			return false, false, err
+		}
+	}
+
+	// we handle the struct literals entirely, no need to call the next defaulters
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(reflect.New(structType)) // Allocate new struct pointer
+		fieldValue.Elem().Set(target)           // Set the actual struct value
+	} else {
+		fieldValue.Set(target) // Direct struct assignment
+	}
+	return false, true, nil
+}
+
+// findFieldFold finds the exported field of the struct type with the name, case-insensitively.
+// The fields of embedded structs are not promoted.
+func findFieldFold(structType reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if field.IsExported() && strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// BoundedNumberDefaulter is a defaulter for numeric fields with bounds in the `min` and `max` tags, e.g.
+// `default:"100" min:"0" max:"50"`. Either of the tags can be omitted.
+//
+// Out-of-range default values are clamped to the bounds if Clamp is set, so the example above yields 50.
+// Otherwise, they result in an [ErrInvalidDefaultValue] error.
+//
+// The default values and the bounds are converted to numbers like the [IntDefaulter], [UintDefaulter],
+// [FloatDefaulter], [DurationDefaulter] and [ExpressionDefaulter] do, and the bounds are checked against the
+// converted numbers, e.g. `default:"90s" max:"1m"` for a [time.Duration] field or `default:"=64*1024" max:"65535"`.
+// The fields without the tags are passed to the next defaulter.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly, with a precedence
+// lower than [PrecedencePrimitiveDefaulter] so that it runs before the defaulters of the numeric kinds.
+type BoundedNumberDefaulter struct {
+	// Clamp clamps the out-of-range default values to the bounds, instead of returning an error.
+	Clamp bool
+}
+
+var _ Defaulter = &BoundedNumberDefaulter{}
+
+func (b *BoundedNumberDefaulter) Name() string {
+	return "defaultz.BoundedNumberDefaulter"
+}
+
+func (b *BoundedNumberDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+	}
+}
+
+//nolint:lll
+func (b *BoundedNumberDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	minStr, hasMin := field.Tag.Lookup("min")
+	maxStr, hasMax := field.Tag.Lookup("max")
+	if !hasMin && !hasMax {
+		return true, false, nil
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	number, err := convertNumber(value, path, field, fieldType)
+	if err != nil {
+		return false, false, err
+	}
+
+	if hasMin {
+		lo, err := convertNumber(minStr, path, field, fieldType)
+		if err != nil {
+			msg := fmt.Sprintf("invalid min '%s': %s", minStr, err.Msg)
+			return false, false, NewError(b, ErrInvalidDefaultValue, path, field, msg)
+		}
+		if compareNumbers(number, lo) < 0 {
+			if !b.Clamp {
+				msg := fmt.Sprintf("value %v is less than the minimum %v", number, lo)
+				return false, false, NewError(b, ErrInvalidDefaultValue, path, field, msg)
+			}
+			number = lo
+		}
+	}
+	if hasMax {
+		hi, err := convertNumber(maxStr, path, field, fieldType)
+		if err != nil {
+			msg := fmt.Sprintf("invalid max '%s': %s", maxStr, err.Msg)
+			return false, false, NewError(b, ErrInvalidDefaultValue, path, field, msg)
+		}
+		if compareNumbers(number, hi) > 0 {
+			if !b.Clamp {
+				msg := fmt.Sprintf("value %v is greater than the maximum %v", number, hi)
+				return false, false, NewError(b, ErrInvalidDefaultValue, path, field, msg)
+			}
+			number = hi
+		}
+	}
+
+	// we handle the bounded fields entirely, no need to call the next defaulters
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new number pointer
+		}
+		fieldValue.Elem().Set(number) // Set the actual number value
+	} else {
+		fieldValue.Set(number) // Direct number assignment
+	}
+	return false, true, nil
+}
+
+// convertNumber converts the value to a number of the type, with the defaulters that would set a field of the type
+// without the bounds, in the order they are registered.
+//
+//nolint:lll
+func convertNumber(value string, path string, field reflect.StructField, numberType reflect.Type) (reflect.Value, *Error) {
+	converters := []Defaulter{&ExpressionDefaulter{}}
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch numberType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		converters = append(converters, &IntDefaulter{})
+	case reflect.Int64:
+		converters = append(converters, &IntDefaulter{}, &DurationDefaulter{})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		converters = append(converters, &UintDefaulter{})
+	case reflect.Float32, reflect.Float64:
+		converters = append(converters, &FloatDefaulter{})
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %v", numberType.Kind()))
+	}
+
+	numberField := field
+	numberField.Type = numberType
+	number := reflect.New(numberType).Elem()
+	var firstErr *Error
+	for _, converter := range converters {
+		callNext, set, err := converter.HandleField(value, path, numberField, number)
+		if set {
+			return number, nil
+		}
+		var fieldErr *Error
+		if firstErr == nil && errors.As(err, &fieldErr) {
+			firstErr = fieldErr
+		}
+		if !callNext {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = NewError(nil, ErrInvalidDefaultValue, path, field, fmt.Sprintf("'%s' is not a number", value))
+	}
+	return reflect.Value{}, firstErr
+}
+
+// compareNumbers compares two numbers of the same kind, like [cmp.Compare].
+func compareNumbers(a, b reflect.Value) int {
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	default:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+}
+
+// ExpressionPrefix is the prefix of the default values that are arithmetic expressions, see [ExpressionDefaulter].
+const ExpressionPrefix = "="
+
+// ExpressionDefaulter is a defaulter for numeric fields with default values that are arithmetic expressions,
+// prefixed with [ExpressionPrefix], e.g. `default:"=1024*1024"` yields 1048576.
+//
+// The expressions are made of integer and floating point literals, the `+ - * /` operators and parentheses.
+// They are evaluated exactly, so `default:"=10/4*2"` yields 5 for an int field. The results that are not integers,
+// or that overflow the field type, are reported with an [ErrInvalidDefaultValue] error for integer fields.
+//
+// The values without the prefix are passed to the next defaulter. It should be registered with a precedence lower
+// than the precedence of the primitive defaulters, e.g. PrecedencePrimitiveDefaulter-1.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly.
+type ExpressionDefaulter struct{}
+
+var _ Defaulter = &ExpressionDefaulter{}
+
+func (e *ExpressionDefaulter) Name() string {
+	return "defaultz.ExpressionDefaulter"
+}
+
+func (e *ExpressionDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+	}
+}
+
+//nolint:lll
+func (e *ExpressionDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	expr, ok := strings.CutPrefix(value, ExpressionPrefix)
+	if !ok {
+		return true, false, nil
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	result, err := evalExpression(expr)
+	if err != nil {
+		return false, false, NewError(e, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid expression '%s': %v", expr, err))
+	}
+
+	number := reflect.New(fieldType).Elem()
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, exact := constant.Int64Val(constant.ToInt(result))
+		if !exact || number.OverflowInt(i) {
+			msg := fmt.Sprintf("result %s of '%s' is not a valid %s", result, expr, fieldType)
+			return false, false, NewError(e, ErrInvalidDefaultValue, path, field, msg)
+		}
+		number.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, exact := constant.Uint64Val(constant.ToInt(result))
+		if !exact || number.OverflowUint(u) {
+			msg := fmt.Sprintf("result %s of '%s' is not a valid %s", result, expr, fieldType)
+			return false, false, NewError(e, ErrInvalidDefaultValue, path, field, msg)
+		}
+		number.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, _ := constant.Float64Val(constant.ToFloat(result))
+		if math.IsInf(f, 0) || number.OverflowFloat(f) {
+			msg := fmt.Sprintf("result %s of '%s' is not a valid %s", result, expr, fieldType)
+			return false, false, NewError(e, ErrInvalidDefaultValue, path, field, msg)
+		}
+		number.SetFloat(f)
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %v", fieldType.Kind()))
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new number pointer
+		}
+		fieldValue.Elem().Set(number) // Set the actual number value
+	} else {
+		fieldValue.Set(number) // Direct number assignment
+	}
+	return false, true, nil
+}
+
+// evalExpression evaluates an arithmetic expression exactly. Only numeric literals, the `+ - * /` operators and
+// parentheses are allowed.
+func evalExpression(expr string) (constant.Value, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, errors.New("syntax error")
+	}
+	return evalNode(node)
+}
+
+func evalNode(node ast.Expr) (constant.Value, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return nil, fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		return constant.MakeFromLiteral(n.Value, n.Kind, 0), nil
+
+	case *ast.ParenExpr:
+		return evalNode(n.X)
+
+	case *ast.UnaryExpr:
+		if n.Op != token.ADD && n.Op != token.SUB {
+			return nil, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		x, err := evalNode(n.X)
+		if err != nil {
+			return nil, err
+		}
+		return constant.UnaryOp(n.Op, x, 0), nil
+
+	case *ast.BinaryExpr:
+		if n.Op != token.ADD && n.Op != token.SUB && n.Op != token.MUL && n.Op != token.QUO {
+			return nil, fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		x, err := evalNode(n.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := evalNode(n.Y)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == token.QUO && constant.Sign(y) == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return constant.BinaryOp(x, n.Op, y), nil
+
+	default:
+		return nil, errors.New("only numbers, the + - * / operators and parentheses are allowed")
+	}
+}
+
+// QuantityDefaulter is a defaulter for integer fields with default values that are quantities with SI or binary
+// suffixes, like the Kubernetes resource quantities, e.g. `default:"256Mi"` yields 268435456 and `default:"1.5G"`
+// yields 1500000000.
+//
+// The binary suffixes are Ki, Mi, Gi, Ti, Pi and Ei, which are powers of 1024. The decimal suffixes are k (or K), M,
+// G, T, P and E, which are powers of 1000. Fractional values are supported as long as the quantity is a whole number,
+// e.g. `default:"0.5Ki"` yields 512.
+//
+// The values without a suffix, and the time.Duration fields, are passed to the next defaulter. It should be registered
+// with a precedence lower than the precedence of the primitive defaulters, e.g. PrecedencePrimitiveDefaulter-1.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly.
+type QuantityDefaulter struct{}
+
+var _ Defaulter = &QuantityDefaulter{}
+
+// quantitySuffixes are the multipliers of the quantity suffixes. The binary suffixes are checked first, as the decimal
+// ones are their prefixes.
+//
+//nolint:gochecknoglobals,mnd	// a lookup table of the suffixes, which are powers of 1024 and 1000
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier *big.Int
+}{
+	{"Ki", new(big.Int).Lsh(big.NewInt(1), 10)},
+	{"Mi", new(big.Int).Lsh(big.NewInt(1), 20)},
+	{"Gi", new(big.Int).Lsh(big.NewInt(1), 30)},
+	{"Ti", new(big.Int).Lsh(big.NewInt(1), 40)},
+	{"Pi", new(big.Int).Lsh(big.NewInt(1), 50)},
+	{"Ei", new(big.Int).Lsh(big.NewInt(1), 60)},
+	{"k", big.NewInt(1e3)},
+	{"K", big.NewInt(1e3)},
+	{"M", big.NewInt(1e6)},
+	{"G", big.NewInt(1e9)},
+	{"T", big.NewInt(1e12)},
+	{"P", big.NewInt(1e15)},
+	{"E", big.NewInt(1e18)},
+}
+
+func (q *QuantityDefaulter) Name() string {
+	return "defaultz.QuantityDefaulter"
+}
+
+func (q *QuantityDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+	}
+}
+
+//nolint:lll
+func (q *QuantityDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == reflect.TypeFor[time.Duration]() {
+		return true, false, nil
+	}
+
+	number, multiplier, ok := cutQuantitySuffix(strings.TrimSpace(value))
+	if !ok {
+		return true, false, nil
+	}
+
+	quantity, err := parseQuantity(number, multiplier)
+	if err != nil {
+		return false, false, NewError(q, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid quantity '%s': %v", value, err))
+	}
+
+	result := reflect.New(fieldType).Elem()
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !quantity.IsInt64() || result.OverflowInt(quantity.Int64()) {
+			return false, false, NewError(q, ErrInvalidDefaultValue, path, field, fmt.Sprintf("quantity '%s' overflows %s", value, fieldType))
+		}
+		result.SetInt(quantity.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !quantity.IsUint64() || result.OverflowUint(quantity.Uint64()) {
+			return false, false, NewError(q, ErrInvalidDefaultValue, path, field, fmt.Sprintf("quantity '%s' overflows %s", value, fieldType))
+		}
+		result.SetUint(quantity.Uint64())
+	default:
+		panic(fmt.Sprintf("unsupported integer type: %v", fieldType.Kind()))
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new number pointer
+		}
+		fieldValue.Elem().Set(result) // Set the actual number value
+	} else {
+		fieldValue.Set(result) // Direct number assignment
+	}
+	return false, true, nil
+}
+
+// cutQuantitySuffix splits the value into the number and the multiplier of its suffix.
+// It returns false if the value doesn't start like a number and end with a quantity suffix, e.g. for enum names.
+func cutQuantitySuffix(value string) (string, *big.Int, bool) {
+	if value == "" || !strings.ContainsRune("0123456789.+-", rune(value[0])) {
+		return "", nil, false
+	}
+	for _, s := range quantitySuffixes {
+		if number, ok := strings.CutSuffix(value, s.suffix); ok {
+			return number, s.multiplier, true
+		}
+	}
+	return "", nil, false
+}
+
+// parseQuantity parses the number, which can be fractional, and multiplies it with the multiplier.
+// The result must be a whole number.
+func parseQuantity(number string, multiplier *big.Int) (*big.Int, error) {
+	if number == "" || strings.ContainsAny(number, "eE/") {
+		return nil, errors.New("must be a number followed by a suffix")
+	}
+	r, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, errors.New("must be a number followed by a suffix")
+	}
+	r.Mul(r, new(big.Rat).SetInt(multiplier))
+	if !r.IsInt() {
+		return nil, errors.New("must be a whole number")
+	}
+	return r.Num(), nil
+}
+
+// FuncDefaulter is a defaulter for function fields, which sets the functions registered with a name, e.g.
+// `default:"noop"` after adding a function with the name "noop". The type of the registered function must be
+// assignable to the type of the field, otherwise an [ErrInvalidDefaultValue] error is returned.
+//
+// See [ExtendedDefaulterRegistry.RegisterFunc] for registering the functions.
+type FuncDefaulter struct {
+	funcs map[string]reflect.Value
+}
+
+var _ Defaulter = &FuncDefaulter{}
+
+// NewFuncDefaulter creates a FuncDefaulter without any functions.
+func NewFuncDefaulter() *FuncDefaulter {
+	return &FuncDefaulter{
+		funcs: make(map[string]reflect.Value),
+	}
+}
+
+// Add adds a function with the name. Adding a name again overwrites the previous function.
+//
+// It panics if fn is not a non-nil function.
+func (f *FuncDefaulter) Add(name string, fn interface{}) *FuncDefaulter {
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func || fnValue.IsNil() {
+		panic(fmt.Sprintf("function '%s' must be a non-nil function, got %T", name, fn))
+	}
+
+	if f.funcs == nil {
+		f.funcs = make(map[string]reflect.Value)
+	}
+	f.funcs[name] = fnValue
+	return f
+}
+
+func (f *FuncDefaulter) Name() string {
+	return "defaultz.FuncDefaulter"
+}
+
+func (f *FuncDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Func}
+}
+
+//nolint:lll
+func (f *FuncDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	fn, ok := f.funcs[value]
+	if !ok {
+		return false, false, NewError(f, ErrInvalidDefaultValue, path, field, fmt.Sprintf("unknown function '%s'", value))
+	}
+	if !fn.Type().AssignableTo(fieldType) {
+		msg := fmt.Sprintf("function '%s' of type %s cannot be assigned to %s", value, fn.Type(), fieldType)
+		return false, false, NewError(f, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new function pointer
+		}
+		fieldValue.Elem().Set(fn) // Set the actual function
+	} else {
+		fieldValue.Set(fn) // Direct function assignment
+	}
+	return false, true, nil
+}
+
+// TypeParserDefaulter is a defaulter for the fields of specific types, which sets the values returned by the parsers
+// added for the types. It is meant for the types of other packages that the basic defaulters cannot handle, such as
+// `decimal.Decimal`, without adding a dependency on them:
+//
+//	NewTypeParserDefaulter().Add(reflect.TypeFor[decimal.Decimal](), func(s string) (reflect.Value, error) {
+//		d, err := decimal.NewFromString(s)
+//		return reflect.ValueOf(d), err
+//	})
+//
+// It handles fields of the added types and pointers to them. The value returned by a parser must be assignable to
+// the type it is added for. The fields of other types are passed to the next defaulter.
+//
+// See [ExtendedDefaulterRegistry.RegisterTypeParser] for registering the parsers.
+type TypeParserDefaulter struct {
+	parsers map[reflect.Type]func(string) (reflect.Value, error)
+}
+
+var _ Defaulter = &TypeParserDefaulter{}
+
+// NewTypeParserDefaulter creates a TypeParserDefaulter without any parsers.
+func NewTypeParserDefaulter() *TypeParserDefaulter {
+	return &TypeParserDefaulter{
+		parsers: make(map[reflect.Type]func(string) (reflect.Value, error)),
+	}
+}
+
+// Add adds a parser for the type. Adding a type again overwrites the previous parser.
+//
+// It panics if the type is nil or a pointer type, or if the parser is nil.
+func (t *TypeParserDefaulter) Add(typ reflect.Type, parser func(string) (reflect.Value, error)) *TypeParserDefaulter {
+	if typ == nil || typ.Kind() == reflect.Ptr {
+		panic(fmt.Sprintf("type parsers must be added for non-pointer types, got %v", typ))
+	}
+	if parser == nil {
+		panic(fmt.Sprintf("parser for type %s must not be nil", typ))
+	}
+
+	if t.parsers == nil {
+		t.parsers = make(map[reflect.Type]func(string) (reflect.Value, error))
+	}
+	t.parsers[typ] = parser
+	return t
+}
+
+func (t *TypeParserDefaulter) Name() string {
+	return "defaultz.TypeParserDefaulter"
+}
+
+// HandledKinds returns the kinds of the added types.
+func (t *TypeParserDefaulter) HandledKinds() []reflect.Kind {
+	kinds := make([]reflect.Kind, 0, len(t.parsers))
+	for typ := range t.parsers {
+		if !slices.Contains(kinds, typ.Kind()) {
+			kinds = append(kinds, typ.Kind())
+		}
+	}
+	return kinds
+}
+
+//nolint:lll
+func (t *TypeParserDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	parser, ok := t.parsers[fieldType]
+	if !ok {
+		return true, false, nil
+	}
+
+	parsed, err := parser(value)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse '%s' as %s: %v", value, fieldType, err)
+		return false, false, NewError(t, ErrInvalidDefaultValue, path, field, msg)
+	}
+	if !parsed.IsValid() || !parsed.Type().AssignableTo(fieldType) {
+		msg := fmt.Sprintf("parser of %s returned a value of type %v", fieldType, typeOf(parsed))
+		return false, false, NewError(t, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new value
+		}
+		fieldValue.Elem().Set(parsed) // Set the actual value
+	} else {
+		fieldValue.Set(parsed) // Direct assignment
+	}
+	return false, true, nil
+}
+
+// typeOf returns the type of the value, or nil if the value is invalid.
+func typeOf(value reflect.Value) reflect.Type {
+	if !value.IsValid() {
+		return nil
+	}
+	return value.Type()
+}
+
+// InterfaceDefaulter is a defaulter for interface fields, which sets the values by unmarshaling the default value as
+// JSON into a concrete type. The concrete type is chosen by the discriminator in the "type" key of the JSON object.
+//
+// For example, after adding the concrete type `&HTTPStep{}` for the `Step` interface with the discriminator "http":
+//
+//   - `default:"{\"type\":\"http\",\"url\":\"https://example.com\"}"` on a Step field yields
+//     `&HTTPStep{URL: "https://example.com"}`
+//
+// The separator of the extractor must not be used in the JSON values, e.g. use ";" instead of ",".
+//
+// See [ExtendedDefaulterRegistry.RegisterInterfaceType] for registering the concrete types.
+type InterfaceDefaulter struct {
+	// DiscriminatorKey is the key of the discriminator in the JSON objects. "type" is used if not set.
+	DiscriminatorKey string
+
+	types map[reflect.Type]map[string]reflect.Type
+}
+
+var _ Defaulter = &InterfaceDefaulter{}
+
+// NewInterfaceDefaulter creates an InterfaceDefaulter without any concrete types.
+func NewInterfaceDefaulter() *InterfaceDefaulter {
+	return &InterfaceDefaulter{
+		types: make(map[reflect.Type]map[string]reflect.Type),
+	}
+}
+
+// Add adds a concrete type for an interface type with a discriminator. The interface type is given as a nil pointer
+// to the interface, e.g. `(*Step)(nil)`, and the concrete type as a value of it, e.g. `&HTTPStep{}`.
+// The fields are set to a pointer if the concrete type is given as a pointer.
+//
+// It panics if iface is not a pointer to an interface or if the concrete type doesn't implement the interface.
+func (i *InterfaceDefaulter) Add(iface interface{}, discriminator string, concrete interface{}) *InterfaceDefaulter {
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType == nil || ifaceType.Kind() != reflect.Ptr || ifaceType.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("interface type must be given as a pointer to an interface, got %T", iface))
+	}
+	ifaceType = ifaceType.Elem()
+
+	concreteType := reflect.TypeOf(concrete)
+	if concreteType == nil || !concreteType.Implements(ifaceType) {
+		panic(fmt.Sprintf("%T does not implement %s", concrete, ifaceType))
+	}
+
+	if i.types == nil {
+		i.types = make(map[reflect.Type]map[string]reflect.Type)
+	}
+	if i.types[ifaceType] == nil {
+		i.types[ifaceType] = make(map[string]reflect.Type)
+	}
+	i.types[ifaceType][discriminator] = concreteType
+	return i
+}
+
+func (i *InterfaceDefaulter) Name() string {
+	return "defaultz.InterfaceDefaulter"
+}
+
+func (i *InterfaceDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Interface}
+}
+
+//nolint:lll
+func (i *InterfaceDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	concreteTypes, ok := i.types[field.Type]
+	if !ok {
+		return true, false, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return false, false, NewError(i, ErrInvalidDefaultValue, path, field, fmt.Sprintf("invalid JSON object: %v", err))
+	}
+
+	key := i.DiscriminatorKey
+	if key == "" {
+		key = "type"
+	}
+	var discriminator string
+	if err := json.Unmarshal(object[key], &discriminator); err != nil {
+		msg := fmt.Sprintf("missing or invalid discriminator '%s': %v", key, err)
+		return false, false, NewError(i, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	concreteType, ok := concreteTypes[discriminator]
+	if !ok {
+		msg := fmt.Sprintf("unknown discriminator '%s' for %s", discriminator, field.Type)
+		return false, false, NewError(i, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	// we handle the registered interface types entirely, no need to call the next defaulters
+	var target reflect.Value
+	if concreteType.Kind() == reflect.Ptr {
+		target = reflect.New(concreteType.Elem())
+	} else {
+		target = reflect.New(concreteType)
+	}
+	if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+		return false, false, NewError(i, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	if concreteType.Kind() == reflect.Ptr {
+		fieldValue.Set(target) // Set the concrete pointer
+	} else {
+		fieldValue.Set(target.Elem()) // Set the concrete value
+	}
+
+	return false, true, nil
+}
+
+// NetAddrParser parses an address, e.g. "127.0.0.1:8080", into a [net.Addr].
+type NetAddrParser func(address string) (net.Addr, error)
+
+// NetAddrDefaulter is a defaulter for [net.Addr] fields, which sets the values by parsing the default value with the
+// parser registered for its scheme. For example, `default:"tcp://127.0.0.1:8080"` yields a [*net.TCPAddr].
+//
+// [NewNetAddrDefaulter] registers parsers for the "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "ip", "ip4", "ip6",
+// "unix", "unixgram" and "unixpacket" schemes. More can be registered with [NetAddrDefaulter.Register].
+// Host names are resolved, like [net.ResolveTCPAddr] does.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly.
+type NetAddrDefaulter struct {
+	parsers map[string]NetAddrParser
+}
+
+var _ Defaulter = &NetAddrDefaulter{}
+
+// NewNetAddrDefaulter creates a NetAddrDefaulter with the parsers for the networks of the net package.
+func NewNetAddrDefaulter() *NetAddrDefaulter {
+	n := &NetAddrDefaulter{}
+	for _, network := range []string{"tcp", "tcp4", "tcp6"} {
+		n.Register(network, func(address string) (net.Addr, error) {
+			return net.ResolveTCPAddr(network, address)
+		})
+	}
+	for _, network := range []string{"udp", "udp4", "udp6"} {
+		n.Register(network, func(address string) (net.Addr, error) {
+			return net.ResolveUDPAddr(network, address)
+		})
+	}
+	for _, network := range []string{"ip", "ip4", "ip6"} {
+		n.Register(network, func(address string) (net.Addr, error) {
+			return net.ResolveIPAddr(network, address)
+		})
+	}
+	for _, network := range []string{"unix", "unixgram", "unixpacket"} {
+		n.Register(network, func(address string) (net.Addr, error) {
+			return net.ResolveUnixAddr(network, address)
+		})
+	}
+	return n
+}
+
+// Register registers the parser for the scheme, replacing the existing one, if any.
+func (n *NetAddrDefaulter) Register(scheme string, parser NetAddrParser) *NetAddrDefaulter {
+	if n.parsers == nil {
+		n.parsers = make(map[string]NetAddrParser)
+	}
+	n.parsers[scheme] = parser
+	return n
+}
+
+func (n *NetAddrDefaulter) Name() string {
+	return "defaultz.NetAddrDefaulter"
+}
+
+func (n *NetAddrDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Interface}
+}
+
+//nolint:lll
+func (n *NetAddrDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	if field.Type != reflect.TypeFor[net.Addr]() {
+		return true, false, nil
+	}
+
+	scheme, address, ok := strings.Cut(value, "://")
+	if !ok {
+		msg := fmt.Sprintf("invalid address '%s', must be in the form scheme://address", value)
+		return false, false, NewError(n, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	parser, ok := n.parsers[scheme]
+	if !ok {
+		return false, false, NewError(n, ErrInvalidDefaultValue, path, field, fmt.Sprintf("unknown scheme '%s'", scheme))
+	}
+
+	addr, err := parser(address)
+	if err != nil {
+		return false, false, NewError(n, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// we handle the net.Addr fields entirely, no need to call the next defaulters
+	fieldValue.Set(reflect.ValueOf(addr))
+	return false, true, nil
+}
+
+// ScannerDefaulter is a defaulter for the fields of types implementing [database/sql.Scanner], such as
+// [database/sql.NullString] or the custom types mapped to database columns.
+// The default value is passed to the Scan method of the field as a string, e.g. `default:"foo"`.
+//
+// The fields of types that don't implement [database/sql.Scanner] on their pointer are passed to the next defaulter.
+// The values rejected by Scan are not passed to the next defaulter.
+//
+// This defaulter is not registered by [WithBasicDefaulters]. It needs to be registered explicitly, with a precedence
+// lower than [PrecedencePrimitiveDefaulter] so that it runs before the defaulters of the underlying kinds.
+type ScannerDefaulter struct{}
+
+var _ Defaulter = &ScannerDefaulter{}
+
+func (s *ScannerDefaulter) Name() string {
+	return "defaultz.ScannerDefaulter"
+}
+
+func (s *ScannerDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+		reflect.String,
+		reflect.Slice,
+		reflect.Map,
+		reflect.Struct,
+	}
+}
+
+//nolint:lll
+func (s *ScannerDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	target := reflect.New(fieldType)
+	scanner, ok := target.Interface().(sql.Scanner)
+	if !ok {
+		return true, false, nil
+	}
+
+	if err := scanner.Scan(value); err != nil {
+		// the value is rejected by the type, the defaulters of the underlying kind must not set it
+		return false, false, NewError(s, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(target) // Set the scanned pointer
+	} else {
+		fieldValue.Set(target.Elem()) // Direct value assignment
+	}
+
+	return false, true, nil
+}
+
+// TextUnmarshalerDefaulter is a defaulter for the fields of types implementing [encoding.TextUnmarshaler], such as
+// [net.IP] or the custom enums, which sets the values by calling UnmarshalText with the default value. The structs
+// implementing it are unmarshaled instead of being recursed into.
+//
+// Both the value and the pointer fields are handled, for the methods with a pointer or a value receiver. The
+// [time.Time] fields are left to the
+// [TimeDefaulter], which supports more formats, and the [netip.Addr] and [netip.Prefix] fields to the
+// [NetIPDefaulter]. The fields of other types are passed to the next defaulter.
+//
+// It is registered by [WithBasicDefaulters] with the [PrecedenceTextUnmarshalerDefaulter], so that it runs before the
+// defaulters of the underlying kinds.
+type TextUnmarshalerDefaulter struct{}
+
+var _ Defaulter = &TextUnmarshalerDefaulter{}
+
+func (t *TextUnmarshalerDefaulter) Name() string {
+	return "defaultz.TextUnmarshalerDefaulter"
+}
+
+func (t *TextUnmarshalerDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{
+		reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64,
+		reflect.String,
+		reflect.Slice,
+		reflect.Array,
+		reflect.Map,
+		reflect.Struct,
+	}
+}
+
+//nolint:lll
+func (t *TextUnmarshalerDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType {
+	case reflect.TypeFor[time.Time](), reflect.TypeFor[netip.Addr](), reflect.TypeFor[netip.Prefix]():
+		// handled by the dedicated defaulters
+		return true, false, nil
+	}
+
+	// the method set of the pointer includes the methods with a value receiver
+	if !reflect.PointerTo(fieldType).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return true, false, nil
+	}
+
+	target := reflect.New(fieldType)
+	unmarshaler := target.Interface().(encoding.TextUnmarshaler) //nolint:forcetypeassert // checked above
+	if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+		msg := fmt.Sprintf("cannot unmarshal '%s' into %s: %v", value, fieldType, err)
+		return false, false, NewError(t, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(target)
+	} else {
+		fieldValue.Set(target.Elem())
+	}
+	return false, true, nil
+}
+
+// JSONUnmarshalerDefaulter is a defaulter for the fields of types implementing [json.Unmarshaler], which sets the
+// values by calling UnmarshalJSON with the default value as a JSON literal, e.g. `default:"{\"a\":1}"`. This allows
+// defaulting the composite types that are not supported otherwise.
+//
+// Both the value and the pointer fields are handled, the pointers are allocated. The types implementing
+// [encoding.TextUnmarshaler] as well, such as [time.Time], are left to the [TextUnmarshalerDefaulter], as their
+// default values are not quoted. The fields of other types are passed to the next defaulter.
+//
+// It is registered by [WithBasicDefaulters] with the [PrecedenceJSONUnmarshalerDefaulter], so that it runs before the
+// defaulters of the underlying kinds.
+type JSONUnmarshalerDefaulter struct{}
+
+var _ Defaulter = &JSONUnmarshalerDefaulter{}
+
+func (j *JSONUnmarshalerDefaulter) Name() string {
+	return "defaultz.JSONUnmarshalerDefaulter"
+}
+
+func (j *JSONUnmarshalerDefaulter) HandledKinds() []reflect.Kind {
+	return (&TextUnmarshalerDefaulter{}).HandledKinds()
+}
+
+//nolint:lll
+func (j *JSONUnmarshalerDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	ptrType := reflect.PointerTo(fieldType)
+	if !ptrType.Implements(reflect.TypeFor[json.Unmarshaler]()) ||
+		ptrType.Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return true, false, nil
+	}
+
+	target := reflect.New(fieldType)
+	unmarshaler := target.Interface().(json.Unmarshaler) //nolint:forcetypeassert // checked above
+	if err := unmarshaler.UnmarshalJSON([]byte(value)); err != nil {
+		msg := fmt.Sprintf("cannot unmarshal '%s' into %s: %v", value, fieldType, err)
+		return false, false, NewError(j, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(target)
+	} else {
+		fieldValue.Set(target.Elem())
+	}
+	return false, true, nil
+}
+
+// convertItemValue converts an item of a slice, an array or a map like convertValue, except that the types
+// implementing [encoding.TextUnmarshaler] on their pointers are converted by calling UnmarshalText.
+func convertItemValue(value string, itemType reflect.Type) (reflect.Value, error) {
+	target := reflect.New(itemType)
+	if unmarshaler, ok := target.Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+			return reflect.Zero(itemType), fmt.Errorf("cannot unmarshal '%s' into %s: %w", value, itemType, err)
+		}
+		return target.Elem(), nil
+	}
+	return convertValue(value, itemType)
+}
+
 // Converts string values to correct type.
 func convertValue(value string, fieldType reflect.Type) (reflect.Value, error) {
 	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
@@ -358,7 +2474,7 @@ func convertValue(value string, fieldType reflect.Type) (reflect.Value, error) {
 		return reflect.ValueOf(f).Convert(fieldType), err
 
 	case reflect.String:
-		return reflect.ValueOf(value), nil
+		return reflect.ValueOf(value).Convert(fieldType), nil
 
 	// we don't support:
 	// - slice of slices
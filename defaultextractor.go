@@ -1,7 +1,10 @@
 package defaultz
 
 import (
+	"fmt"
+	"os"
 	"reflect"
+	"regexp"
 	"strings"
 )
 
@@ -13,8 +16,36 @@ type DefaultExtractor interface {
 	ExtractDefault(field reflect.StructField) (defaultStr string, found bool, err error)
 }
 
+// PathDefaultExtractor is an optional interface for the DefaultExtractor implementations that need the path of the
+// field, e.g. `<root>.Server.Port`, to extract the default value.
+//
+// The registry calls ExtractDefaultAt instead of ExtractDefault when the extractor implements this interface and the
+// path of the field is known.
+type PathDefaultExtractor interface {
+	DefaultExtractor
+
+	// ExtractDefaultAt extracts the default value as string for the field at the path.
+	ExtractDefaultAt(path string, field reflect.StructField) (defaultStr string, found bool, err error)
+}
+
+// extractDefaultAt extracts the default value with the path, if the extractor supports it.
+func extractDefaultAt(extractor DefaultExtractor, path string, field reflect.StructField) (string, bool, error) {
+	if pathExtractor, ok := extractor.(PathDefaultExtractor); ok {
+		return pathExtractor.ExtractDefaultAt(path, field)
+	}
+	return extractor.ExtractDefault(field)
+}
+
 var _ DefaultExtractor = &DefaultzExtractor{}
 
+// DefaultFromTag is the tag that makes the DefaultzExtractor read the default value of a field from another tag, e.g.
+// `defaultFrom:"legacy" legacy:"foo"` yields "foo" regardless of the TagName of the extractor.
+const DefaultFromTag = "defaultFrom"
+
+// DefaultPrefixTag is the tag that overrides the prefix of the DefaultzExtractor for a single field, e.g.
+// `jsonschema:"title=port,value=8080" defaultPrefix:"value="` yields "8080" regardless of the Prefix of the extractor.
+const DefaultPrefixTag = "defaultPrefix"
+
 // DefaultzExtractor is a DefaultExtractor implementation that extracts the default value from the tag of a struct
 // field.
 //
@@ -33,6 +64,12 @@ var _ DefaultExtractor = &DefaultzExtractor{}
 // `jsonschema:"title=myfield,default=foo"` will yield "foo", if the tag name is "jsonschema", the prefix is "default="
 // and the separator is ",".
 //
+// A field can opt into a different tag with the [DefaultFromTag], e.g. `defaultFrom:"legacy" legacy:"foo"` will yield
+// "foo". The prefix and the separator are the same for the other tag.
+//
+// A field can override the prefix with the [DefaultPrefixTag], e.g. `default:"value=foo" defaultPrefix:"value="` will
+// yield "foo". An empty prefix, `defaultPrefix:""`, makes the first part of the tag the default value.
+//
 //nolint:revive		// Extractor would be a too generic name. Here, we're extracting default values.
 type DefaultzExtractor struct {
 
@@ -79,7 +116,16 @@ type DefaultzExtractor struct {
 	//		 }
 	//
 	// the separator should be set to "," to be able to extract the default value "hello".
+	//
+	// The tag value is not split within brackets, braces and double-quoted strings, so that JSON literals like
+	// `default:"[1,2,3]"` are kept whole. If they are not balanced, the tag value is split at every separator.
 	Separator string
+
+	// CaseInsensitivePrefix makes the prefix match regardless of case.
+	//
+	// For example, if the prefix is "default=", both `jsonschema:"Default=hello"` and `jsonschema:"default=hello"`
+	// will yield "hello". The value itself is kept verbatim.
+	CaseInsensitivePrefix bool
 }
 
 func NewDefaultzExtractor(tagName, prefix, separator string) DefaultExtractor {
@@ -90,8 +136,18 @@ func NewDefaultzExtractor(tagName, prefix, separator string) DefaultExtractor {
 	}
 }
 
+func (d DefaultzExtractor) String() string {
+	return fmt.Sprintf("defaultz.DefaultzExtractor{TagName: %q, Prefix: %q, Separator: %q, CaseInsensitivePrefix: %t}",
+		d.TagName, d.Prefix, d.Separator, d.CaseInsensitivePrefix)
+}
+
 func (d DefaultzExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
-	tag, ok := field.Tag.Lookup(d.TagName)
+	tagName := d.TagName
+	if from := field.Tag.Get(DefaultFromTag); from != "" {
+		tagName = from
+	}
+
+	tag, ok := field.Tag.Lookup(tagName)
 	if !ok {
 		return "", false, nil
 	}
@@ -100,14 +156,285 @@ func (d DefaultzExtractor) ExtractDefault(field reflect.StructField) (string, bo
 		return "", false, nil
 	}
 
-	// split the tag value by separator
-	tagParts := strings.Split(tag, d.Separator)
+	prefix := d.Prefix
+	if override, ok := field.Tag.Lookup(DefaultPrefixTag); ok {
+		prefix = override
+	}
+
+	// split the tag value by separator, keeping the JSON literals whole
+	tagParts := splitTag(tag, d.Separator)
 	for _, tagPart := range tagParts {
 		tagPart = strings.TrimSpace(tagPart)
-		if strings.HasPrefix(tagPart, d.Prefix) {
-			return strings.TrimPrefix(tagPart, d.Prefix), true, nil
+		if value, ok := d.cutPrefix(tagPart, prefix); ok {
+			return value, true, nil
 		}
 	}
 
 	return "", false, nil
 }
+
+// splitTag splits the tag value by the separator, except within brackets, braces and double-quoted strings, so that
+// JSON literals like `[1,2,3]` or `{"a":1,"b":2}` are kept whole. If the brackets or the quotes are not balanced, the
+// tag value is split at every separator.
+func splitTag(tag string, separator string) []string {
+	if separator == "" {
+		return strings.Split(tag, separator)
+	}
+
+	var parts []string
+	depth := 0
+	inString := false
+	escaped := false
+	start := 0
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			if depth == 0 {
+				return strings.Split(tag, separator)
+			}
+			depth--
+		case depth == 0 && strings.HasPrefix(tag[i:], separator):
+			parts = append(parts, tag[start:i])
+			start = i + len(separator)
+			i = start - 1
+		}
+	}
+	if depth != 0 || inString {
+		return strings.Split(tag, separator)
+	}
+	return append(parts, tag[start:])
+}
+
+func (d DefaultzExtractor) cutPrefix(tagPart string, prefix string) (string, bool) {
+	if !d.CaseInsensitivePrefix {
+		return strings.CutPrefix(tagPart, prefix)
+	}
+	if len(tagPart) < len(prefix) || !strings.EqualFold(tagPart[:len(prefix)], prefix) {
+		return "", false
+	}
+	return tagPart[len(prefix):], true
+}
+
+// PatternRule is a rule of a PatternExtractor.
+type PatternRule struct {
+	// Pattern is matched against the name of the field.
+	Pattern *regexp.Regexp
+
+	// Default is the default value of the fields with a name matching the Pattern.
+	Default string
+}
+
+var _ DefaultExtractor = &PatternExtractor{}
+
+// PatternExtractor is a DefaultExtractor implementation that yields default values based on the names of the fields,
+// without requiring any tags.
+//
+// The rules are checked in order and the first rule with a matching pattern wins.
+//
+// For example, with a rule of `Count$` -> "0", all fields with names ending in "Count" get the default value "0".
+//
+// Use it with [NewChainExtractor] to combine it with a tag based extractor.
+type PatternExtractor struct {
+	// Rules are the rules to be checked in order.
+	Rules []PatternRule
+}
+
+func NewPatternExtractor(rules ...PatternRule) DefaultExtractor {
+	return &PatternExtractor{
+		Rules: rules,
+	}
+}
+
+func (p PatternExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
+	for _, rule := range p.Rules {
+		if rule.Pattern.MatchString(field.Name) {
+			return rule.Default, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+var _ PathDefaultExtractor = &ChainExtractor{}
+
+// ChainExtractor is a DefaultExtractor implementation that asks multiple extractors in order and yields the default
+// value of the first extractor that finds one.
+//
+// For example, a chain of a DefaultzExtractor and a PatternExtractor uses the tag of the field if it has one, and
+// falls back to the name patterns otherwise.
+//
+// Extraction stops at the first error.
+type ChainExtractor struct {
+	// Extractors are the extractors to be asked in order.
+	Extractors []DefaultExtractor
+}
+
+func NewChainExtractor(extractors ...DefaultExtractor) DefaultExtractor {
+	return &ChainExtractor{
+		Extractors: extractors,
+	}
+}
+
+func (c ChainExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
+	for _, extractor := range c.Extractors {
+		defaultStr, found, err := extractor.ExtractDefault(field)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			return defaultStr, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// ExtractDefaultAt is like ExtractDefault, but passes the path to the extractors implementing
+// [PathDefaultExtractor].
+func (c ChainExtractor) ExtractDefaultAt(path string, field reflect.StructField) (string, bool, error) {
+	for _, extractor := range c.Extractors {
+		defaultStr, found, err := extractDefaultAt(extractor, path, field)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			return defaultStr, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+var _ PathDefaultExtractor = &EnvPathExtractor{}
+
+// EnvPathExtractor is a DefaultExtractor implementation that reads the default values from environment variables
+// with names derived from the paths of the fields.
+//
+// The root of the path is dropped, the rest of the path is upper-cased, and the prefix and the parts of the path
+// are joined with the separator. For example, with the prefix "APP" and the separator "_":
+//
+//   - `<root>.Server.Port` maps to APP_SERVER_PORT
+//   - `<root>.Servers[0].Port` maps to APP_SERVERS_0_PORT
+//
+// Without the path, e.g. when asked by a registry for a type instead of a field, only the name of the field is used.
+//
+// Use it with [NewChainExtractor] to combine it with a tag based extractor, e.g. the environment variables first and
+// the tags as the fallback.
+type EnvPathExtractor struct {
+	// Prefix is the prefix of the names of the environment variables. It is omitted if empty.
+	Prefix string
+
+	// Separator is the separator between the prefix and the parts of the path.
+	Separator string
+}
+
+func NewEnvPathExtractor(prefix, separator string) DefaultExtractor {
+	return &EnvPathExtractor{
+		Prefix:    prefix,
+		Separator: separator,
+	}
+}
+
+func (e EnvPathExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
+	value, found := os.LookupEnv(e.EnvName(field.Name))
+	return value, found, nil
+}
+
+func (e EnvPathExtractor) ExtractDefaultAt(path string, field reflect.StructField) (string, bool, error) {
+	value, found := os.LookupEnv(e.EnvName(relativePath(path)))
+	return value, found, nil
+}
+
+// EnvName returns the name of the environment variable for the path relative to the root, e.g. `Server.Port`.
+func (e EnvPathExtractor) EnvName(path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+	if e.Prefix != "" {
+		parts = append([]string{e.Prefix}, parts...)
+	}
+	return strings.ToUpper(strings.Join(parts, e.Separator))
+}
+
+// relativePath drops the root of a path, e.g. `<root>.Server.Port` or `pkg.(Config).Server.Port` yields
+// `Server.Port`.
+func relativePath(path string) string {
+	// the field names cannot contain parentheses, so the last one closes the name of the root type
+	if i := strings.LastIndex(path, ")."); i >= 0 {
+		return path[i+2:]
+	}
+	if i := strings.Index(path, "<root>."); i >= 0 {
+		return path[i+len("<root>."):]
+	}
+	return path
+}
+
+var _ DefaultExtractor = &EnvTagExtractor{}
+
+// EnvTagExtractor is a DefaultExtractor implementation that reads the default values from the environment variables
+// named in the tags of the fields, e.g. `env:"PORT"`. The name is prefixed with the prefix, if it is not empty.
+//
+// The name can be followed by comma-separated options. The only supported option is "required", e.g.
+// `env:"PORT,required"`, which makes applying the defaults fail with an error with the path of the field and the name
+// of the environment variable, if the environment variable is not set.
+// Otherwise, unset environment variables are not found, and the next extractor in a [ChainExtractor] is asked.
+type EnvTagExtractor struct {
+	// TagName is the name of the tag with the name of the environment variable, e.g. "env".
+	TagName string
+
+	// Prefix is prepended to the names of the environment variables, e.g. "APP_". It is omitted if empty.
+	Prefix string
+}
+
+func NewEnvTagExtractor(tagName, prefix string) DefaultExtractor {
+	return &EnvTagExtractor{
+		TagName: tagName,
+		Prefix:  prefix,
+	}
+}
+
+func (e EnvTagExtractor) String() string {
+	return fmt.Sprintf("defaultz.EnvTagExtractor{TagName: %q, Prefix: %q}", e.TagName, e.Prefix)
+}
+
+func (e EnvTagExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
+	tag, ok := field.Tag.Lookup(e.TagName)
+	if !ok {
+		return "", false, nil
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", false, nil
+	}
+
+	required := false
+	if options != "" {
+		for _, option := range strings.Split(options, ",") {
+			switch option = strings.TrimSpace(option); option {
+			case "required":
+				required = true
+			default:
+				return "", false, fmt.Errorf("unknown option '%s' for environment variable '%s'", option, e.Prefix+name)
+			}
+		}
+	}
+
+	value, found := os.LookupEnv(e.Prefix + name)
+	if !found && required {
+		return "", false, fmt.Errorf("required environment variable '%s' is not set", e.Prefix+name)
+	}
+	return value, found, nil
+}
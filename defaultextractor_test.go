@@ -1,7 +1,10 @@
 package defaultz_test
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -92,3 +95,390 @@ func TestDefaultzExtractor_ExtractDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultzExtractor_ExtractDefaultCaseInsensitivePrefix(t *testing.T) {
+	type mixedCase struct {
+		Lower    string `jsonschema:"title=x,default=foo"`
+		Title    string `jsonschema:"title=x,Default=Bar"`
+		Upper    string `jsonschema:"DEFAULT=BaZ,title=x"`
+		Short    string `jsonschema:"def"`
+		NoPrefix string `jsonschema:"title=x"`
+	}
+
+	tests := []struct {
+		fieldName       string
+		caseInsensitive bool
+		expected        string
+		ok              bool
+	}{
+		{"Lower", true, "foo", true},
+		{"Title", true, "Bar", true},
+		{"Upper", true, "BaZ", true},
+		{"Short", true, "", false},
+		{"NoPrefix", true, "", false},
+
+		{"Lower", false, "foo", true},
+		{"Title", false, "", false},
+		{"Upper", false, "", false},
+	}
+
+	testType := reflect.TypeOf(mixedCase{})
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%t", tt.fieldName, tt.caseInsensitive), func(t *testing.T) {
+			extractor := &defaultz.DefaultzExtractor{
+				TagName:               "jsonschema",
+				Prefix:                "default=",
+				Separator:             ",",
+				CaseInsensitivePrefix: tt.caseInsensitive,
+			}
+
+			field, _ := testType.FieldByName(tt.fieldName)
+
+			result, ok, err := extractor.ExtractDefault(field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDefaultzExtractor_ExtractDefaultFrom(t *testing.T) {
+	type withDefaultFrom struct {
+		Default       string `default:"foo"`
+		Legacy        string `defaultFrom:"legacy" legacy:"bar" default:"ignored"`
+		MissingLegacy string `defaultFrom:"legacy" default:"ignored"`
+		EmptyFrom     string `defaultFrom:"" default:"baz"`
+	}
+
+	tests := []struct {
+		fieldName string
+		expected  string
+		ok        bool
+	}{
+		{"Default", "foo", true},
+		{"Legacy", "bar", true},
+		{"MissingLegacy", "", false},
+		{"EmptyFrom", "baz", true},
+	}
+
+	testType := reflect.TypeOf(withDefaultFrom{})
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			extractor := defaultz.NewDefaultzExtractor("default", "", ",")
+
+			field, _ := testType.FieldByName(tt.fieldName)
+
+			result, ok, err := extractor.ExtractDefault(field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDefaultzExtractor_ExtractDefaultPrefix(t *testing.T) {
+	type withDefaultPrefix struct {
+		Default     string `jsonschema:"title=name,default=foo"`
+		Overridden  string `jsonschema:"title=port,value=8080,default=ignored" defaultPrefix:"value="`
+		CaseOnly    string `jsonschema:"title=host,VALUE=localhost" defaultPrefix:"value="`
+		EmptyPrefix string `jsonschema:"bar,title=empty" defaultPrefix:""`
+		Missing     string `jsonschema:"title=missing,default=ignored" defaultPrefix:"value="`
+		FromOther   string `defaultFrom:"legacy" legacy:"title=legacy,value=baz" defaultPrefix:"value="`
+	}
+
+	tests := []struct {
+		fieldName       string
+		caseInsensitive bool
+		expected        string
+		ok              bool
+	}{
+		{"Default", false, "foo", true},
+		{"Overridden", false, "8080", true},
+		{"CaseOnly", false, "", false},
+		{"CaseOnly", true, "localhost", true},
+		{"EmptyPrefix", false, "bar", true},
+		{"Missing", false, "", false},
+		{"FromOther", false, "baz", true},
+	}
+
+	testType := reflect.TypeOf(withDefaultPrefix{})
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			extractor := &defaultz.DefaultzExtractor{
+				TagName:               "jsonschema",
+				Prefix:                "default=",
+				Separator:             ",",
+				CaseInsensitivePrefix: tt.caseInsensitive,
+			}
+
+			field, _ := testType.FieldByName(tt.fieldName)
+
+			result, ok, err := extractor.ExtractDefault(field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDefaultzExtractor_ExtractDefaultJSON(t *testing.T) {
+	type withJSON struct {
+		Array      string `default:"[1,2,3]"`
+		Object     string `default:"{\"a\":1,\"b\":[2,3]}"`
+		Quoted     string `default:"\"a,]b\""`
+		Prefixed   string `jsonschema:"title=x,default=[1,2],name=y"`
+		Unbalanced string `default:"[1,2"`
+		Unopened   string `default:"1],2"`
+		Unquoted   string `default:"\"a,b"`
+	}
+
+	tests := []struct {
+		fieldName string
+		tagName   string
+		prefix    string
+		expected  string
+	}{
+		{"Array", "default", "", "[1,2,3]"},
+		{"Object", "default", "", `{"a":1,"b":[2,3]}`},
+		{"Quoted", "default", "", `"a,]b"`},
+		{"Prefixed", "jsonschema", "default=", "[1,2]"},
+		{"Unbalanced", "default", "", "[1"},
+		{"Unopened", "default", "", "1]"},
+		{"Unquoted", "default", "", `"a`},
+	}
+
+	testType := reflect.TypeOf(withJSON{})
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			extractor := defaultz.NewDefaultzExtractor(tt.tagName, tt.prefix, ",")
+
+			field, _ := testType.FieldByName(tt.fieldName)
+
+			result, ok, err := extractor.ExtractDefault(field)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPatternExtractor_ExtractDefault(t *testing.T) {
+	type patternStruct struct {
+		RetryCount   int
+		MaxCount     int `default:"5"`
+		Name         string
+		NameOverride string
+		Counter      int
+	}
+
+	extractor := defaultz.NewPatternExtractor(
+		defaultz.PatternRule{Pattern: regexp.MustCompile(`Count$`), Default: "0"},
+		defaultz.PatternRule{Pattern: regexp.MustCompile(`^Name`), Default: "foo"},
+		defaultz.PatternRule{Pattern: regexp.MustCompile(`^NameOverride$`), Default: "unreachable"},
+	)
+
+	tests := []struct {
+		fieldName string
+		expected  string
+		ok        bool
+	}{
+		{"RetryCount", "0", true},
+		{"MaxCount", "0", true},
+		{"Name", "foo", true},
+		{"NameOverride", "foo", true}, // first matching rule wins
+		{"Counter", "", false},
+	}
+
+	testType := reflect.TypeOf(patternStruct{})
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			field, _ := testType.FieldByName(tt.fieldName)
+
+			result, ok, err := extractor.ExtractDefault(field)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+type failingExtractor struct{}
+
+func (f failingExtractor) ExtractDefault(_ reflect.StructField) (string, bool, error) {
+	return "", false, errors.New("failing extractor")
+}
+
+func TestChainExtractor_ExtractDefault(t *testing.T) {
+	obj := &struct {
+		RetryCount int
+		MaxCount   int `default:"5"`
+		Name       string
+		Other      string `default:"bar"`
+	}{}
+
+	reg := defaultz.NewDefaulterRegistry(
+		defaultz.WithBasicDefaulters(),
+		defaultz.WithDefaultExtractor(defaultz.NewChainExtractor(
+			defaultz.NewDefaultzExtractor("default", "", ","),
+			defaultz.NewPatternExtractor(
+				defaultz.PatternRule{Pattern: regexp.MustCompile(`Count$`), Default: "3"},
+			),
+		)),
+	)
+
+	err := reg.ApplyDefaults(obj)
+	require.NoError(t, err)
+	assert.Equal(t, 3, obj.RetryCount)
+	assert.Equal(t, 5, obj.MaxCount)
+	assert.Empty(t, obj.Name)
+	assert.Equal(t, "bar", obj.Other)
+
+	failing := defaultz.NewChainExtractor(failingExtractor{}, defaultz.NewDefaultzExtractor("default", "", ","))
+	field, _ := reflect.TypeOf(obj).Elem().FieldByName("Other")
+	_, ok, err := failing.ExtractDefault(field)
+	require.EqualError(t, err, "failing extractor")
+	assert.False(t, ok)
+}
+
+func TestEnvPathExtractor_EnvName(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		separator string
+		path      string
+		expected  string
+	}{
+		{"APP", "_", "Port", "APP_PORT"},
+		{"APP", "_", "Server.Port", "APP_SERVER_PORT"},
+		{"APP", "_", "Servers[0].Port", "APP_SERVERS_0_PORT"},
+		{"", "_", "Server.Port", "SERVER_PORT"},
+		{"app", "__", "Server.Port", "APP__SERVER__PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			extractor := defaultz.EnvPathExtractor{Prefix: tt.prefix, Separator: tt.separator}
+			assert.Equal(t, tt.expected, extractor.EnvName(tt.path))
+		})
+	}
+}
+
+type envServer struct {
+	Host string `default:"localhost"`
+	Port int    `default:"8080"`
+}
+
+type envConfig struct {
+	Name    string
+	Server  envServer
+	Backup  *envServer
+	Servers []envServer
+}
+
+func TestEnvPathExtractor_ExtractDefault(t *testing.T) {
+	t.Setenv("APP_NAME", "myapp")
+	t.Setenv("APP_SERVER_PORT", "9090")
+	t.Setenv("APP_BACKUP_HOST", "backup.local")
+	t.Setenv("APP_SERVERS_1_PORT", "9191")
+
+	reg := defaultz.NewDefaulterRegistry(
+		defaultz.WithBasicDefaulters(),
+		defaultz.WithDefaultExtractor(defaultz.NewChainExtractor(
+			defaultz.NewEnvPathExtractor("APP", "_"),
+			defaultz.NewDefaultzExtractor("default", "", ","),
+		)),
+	)
+
+	obj := &envConfig{Servers: make([]envServer, 2)}
+	err := reg.ApplyDefaults(obj)
+	require.NoError(t, err)
+
+	assert.Equal(t, "myapp", obj.Name)
+	assert.Equal(t, envServer{Host: "localhost", Port: 9090}, obj.Server)
+	assert.Equal(t, &envServer{Host: "backup.local", Port: 8080}, obj.Backup)
+	assert.Equal(t, []envServer{{Host: "localhost", Port: 8080}, {Host: "localhost", Port: 9191}}, obj.Servers)
+
+	// without the path, only the name of the field is used
+	field, _ := reflect.TypeOf(envConfig{}).FieldByName("Name")
+	value, ok, err := defaultz.NewEnvPathExtractor("APP", "_").ExtractDefault(field)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "myapp", value)
+}
+
+func TestEnvPathExtractor_ExtractDefaultAnonymousRoot(t *testing.T) {
+	t.Setenv("APP_SERVER_HOST", "example.com")
+
+	obj := &struct {
+		Server envServer
+	}{}
+
+	reg := defaultz.NewDefaulterRegistry(
+		defaultz.WithBasicDefaulters(),
+		defaultz.WithDefaultExtractor(defaultz.NewEnvPathExtractor("APP", "_")),
+		defaultz.WithRootLabel("label"),
+	)
+
+	require.NoError(t, reg.ApplyDefaults(obj))
+	assert.Equal(t, envServer{Host: "example.com"}, obj.Server)
+}
+
+func TestEnvTagExtractor_ExtractDefault(t *testing.T) {
+	type config struct {
+		Port    int    `env:"PORT,required" default:"8080"`
+		Host    string `env:"HOST" default:"localhost"`
+		Debug   bool   `env:"DEBUG, required"`
+		Timeout int    `default:"30"`
+	}
+	newRegistry := func() defaultz.DefaulterRegistry {
+		return defaultz.NewDefaulterRegistry(
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(defaultz.NewChainExtractor(
+				defaultz.NewEnvTagExtractor("env", "APP_"),
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			)),
+		)
+	}
+
+	t.Run("Required set", func(t *testing.T) {
+		t.Setenv("APP_PORT", "9090")
+		t.Setenv("APP_DEBUG", "true")
+
+		obj := &config{}
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, config{Port: 9090, Host: "localhost", Debug: true, Timeout: 30}, *obj)
+	})
+
+	t.Run("Required unset", func(t *testing.T) {
+		t.Setenv("APP_DEBUG", "true")
+
+		obj := &config{}
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrCannotExtractDefault)
+
+		var defaultzErr *defaultz.Error
+		require.ErrorAs(t, err, &defaultzErr)
+		assert.Equal(t, "Port", defaultzErr.Field.Name)
+		assert.EqualError(t, err, "cannot extract default value - "+
+			"required environment variable 'APP_PORT' is not set, "+
+			"path:'github.com/aliok/go-defaultz_test.(config).Port`, "+
+			"field:'Port int `env:\"PORT,required\" default:\"8080\"`'")
+		assert.Equal(t, config{}, *obj)
+	})
+
+	t.Run("Required set on existing value", func(t *testing.T) {
+		t.Setenv("APP_DEBUG", "true")
+
+		// the extractor is not asked for the fields with non-zero values
+		obj := &config{Port: 1}
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, 1, obj.Port)
+	})
+
+	t.Run("Unknown option", func(t *testing.T) {
+		field := reflect.StructField{Name: "Port", Tag: `env:"PORT,optional"`}
+		_, _, err := defaultz.NewEnvTagExtractor("env", "").ExtractDefault(field)
+		require.EqualError(t, err, "unknown option 'optional' for environment variable 'PORT'")
+	})
+}
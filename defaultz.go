@@ -1,10 +1,16 @@
 package defaultz
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 )
@@ -25,11 +31,181 @@ var instance = NewDefaulterRegistry(
 const PrecedencePrimitiveDefaulter = 1000
 const PrecedenceOtherDefaulter = 2000
 
+// PrecedenceEnumDefaulter is the precedence of the [EnumDefaulter] registered by
+// [ExtendedDefaulterRegistry.RegisterEnum]. It runs before the primitive defaulters, as the names of the enum values
+// cannot be parsed by them.
+const PrecedenceEnumDefaulter = 500
+
+// PrecedenceTypeParserDefaulter is the precedence of the [TypeParserDefaulter] registered by
+// [ExtendedDefaulterRegistry.RegisterTypeParser]. It runs before the other defaulters, as the parsers are registered
+// for specific types.
+const PrecedenceTypeParserDefaulter = 400
+
+// PrecedenceTextUnmarshalerDefaulter is the precedence of the [TextUnmarshalerDefaulter] registered by
+// [WithBasicDefaulters]. It runs before the primitive defaulters, as the types implementing [encoding.TextUnmarshaler]
+// define how they are parsed, but after the enums and the parsers registered for specific types.
+const PrecedenceTextUnmarshalerDefaulter = 600
+
+// PrecedenceJSONUnmarshalerDefaulter is the precedence of the [JSONUnmarshalerDefaulter] registered by
+// [WithBasicDefaulters]. It runs after the [TextUnmarshalerDefaulter] and before the primitive defaulters.
+const PrecedenceJSONUnmarshalerDefaulter = 700
+
+// ConstantPrefix is the prefix of default values that reference a constant registered with
+// [ExtendedDefaulterRegistry.RegisterConstants], e.g. `default:"const:DefaultPort"`.
+const ConstantPrefix = "const:"
+
 // ApplyDefaults applies default values to the struct using the basic defaulters.
 func ApplyDefaults(obj interface{}) error {
 	return instance.ApplyDefaults(obj)
 }
 
+// ApplyDefaultsForGroup applies the default values of the fields in the group to the struct using the basic
+// defaulters. See [ExtendedDefaulterRegistry.ApplyDefaultsForGroup] for more information.
+func ApplyDefaultsForGroup(obj interface{}, group string) error {
+	return instance.ApplyDefaultsForGroup(obj, group)
+}
+
+// WithDefaults returns a deep copy of the struct with the default values applied using the basic defaulters, leaving
+// the struct untouched. See [ExtendedDefaulterRegistry.WithDefaults] for more information.
+func WithDefaults(obj interface{}) (interface{}, error) {
+	return instance.WithDefaults(obj)
+}
+
+// ApplyDefaultsTo applies default values to the struct using the basic defaulters, and returns the same pointer, so
+// that a struct can be created and defaulted in one line:
+//
+//	cfg, err := defaultz.ApplyDefaultsTo(&Config{})
+//
+// The result is nil if there is an error. See [ApplyDefaultsToWith] for using another registry.
+func ApplyDefaultsTo[T any](obj *T) (*T, error) {
+	return ApplyDefaultsToWith(instance, obj)
+}
+
+// ApplyDefaultsToWith applies default values to the struct using the registry, and returns the same pointer like
+// [ApplyDefaultsTo].
+//
+// T must be a struct type, which cannot be expressed as a type constraint, so other types are rejected with an error
+// like [DefaulterRegistry.ApplyDefaults] does.
+func ApplyDefaultsToWith[T any](r DefaulterRegistry, obj *T) (*T, error) {
+	if err := r.ApplyDefaults(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ApplyDefaultsWithReport applies default values to the struct using the basic defaulters, and returns the fields
+// that are set. See [ExtendedDefaulterRegistry.ApplyDefaultsWithReport] for more information.
+func ApplyDefaultsWithReport(obj interface{}) (Report, error) {
+	return instance.ApplyDefaultsWithReport(obj)
+}
+
+// ApplyDefaultsWithTrace applies default values to the struct using the basic defaulters, and returns the decision
+// log of the call. See [ExtendedDefaulterRegistry.ApplyDefaultsWithTrace] for more information.
+func ApplyDefaultsWithTrace(obj interface{}) (Trace, error) {
+	return instance.ApplyDefaultsWithTrace(obj)
+}
+
+// ApplyDefaultsWithPrototype applies default values to the struct using the basic defaulters, falling back to the
+// values of the prototype. See [ExtendedDefaulterRegistry.ApplyDefaultsWithPrototype] for more information.
+func ApplyDefaultsWithPrototype(obj interface{}, prototype interface{}) error {
+	return instance.ApplyDefaultsWithPrototype(obj, prototype)
+}
+
+// ApplyDefaultsFromJSONTree applies default values to the struct from a tree of default values and the tags, using the
+// basic defaulters. See [ExtendedDefaulterRegistry.ApplyDefaultsFromJSONTree] for more information.
+func ApplyDefaultsFromJSONTree(obj interface{}, tree map[string]any) error {
+	return instance.ApplyDefaultsFromJSONTree(obj, tree)
+}
+
+// ApplyDefaultsStrictComplete applies default values to the struct using the basic defaulters, and reports the
+// fields left zero without a default value.
+// See [ExtendedDefaulterRegistry.ApplyDefaultsStrictComplete] for more information.
+func ApplyDefaultsStrictComplete(obj interface{}) error {
+	return instance.ApplyDefaultsStrictComplete(obj)
+}
+
+// Validate checks the default values in the tags of the struct type using the basic defaulters.
+// See [ExtendedDefaulterRegistry.Validate] for more information.
+func Validate(t reflect.Type) error {
+	return instance.Validate(t)
+}
+
+// ValidateTypes checks the default values in the tags of the struct types concurrently using the basic defaulters.
+// See [ExtendedDefaulterRegistry.ValidateTypes] for more information.
+func ValidateTypes(types ...reflect.Type) error {
+	return instance.ValidateTypes(types...)
+}
+
+// ApplyDefaultsWithReport applies default values to the struct like [DefaulterRegistry.ApplyDefaults], and returns the
+// fields that are set with the values applied to them. The fields that are left alone, e.g. because they are already
+// set, are not in the report. Use [ExtendedDefaulterRegistry.ApplyDefaultsWithTrace] to find out why.
+//
+// The report is returned with the error as well, up to the field that failed.
+func (r *defaulterRegistry) ApplyDefaultsWithReport(obj interface{}) (Report, error) {
+	trace, err := r.ApplyDefaultsWithTrace(obj)
+	return newReport(trace), err
+}
+
+// ApplyDefaultsStream applies default values to the structs received from objs using the basic defaulters.
+// See [ExtendedDefaulterRegistry.ApplyDefaultsStream] for more information.
+func ApplyDefaultsStream(ctx context.Context, objs <-chan interface{}, results chan<- error) error {
+	return instance.ApplyDefaultsStream(ctx, objs, results)
+}
+
+// RegisterConstants registers named values for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterConstants] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterConstants(constants map[string]string) {
+	instance.RegisterConstants(constants)
+}
+
+// RegisterMacro registers a macro for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterMacro] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterMacro(name string, value string) {
+	instance.RegisterMacro(name, value)
+}
+
+// RegisterEnum registers the names of the values of an enum type for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterEnum] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterEnum(names interface{}) {
+	instance.RegisterEnum(names)
+}
+
+// RegisterInterfaceType registers a concrete type of an interface type for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterInterfaceType] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterInterfaceType(iface interface{}, discriminator string, concrete interface{}) {
+	instance.RegisterInterfaceType(iface, discriminator, concrete)
+}
+
+// RegisterFunc registers a function with a name for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterFunc] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterFunc(name string, fn interface{}) {
+	instance.RegisterFunc(name, fn)
+}
+
+// RegisterTypeParser registers a parser of a type for the registry used by [ApplyDefaults].
+// See [ExtendedDefaulterRegistry.RegisterTypeParser] for more information.
+//
+// This is not safe to call concurrently with [ApplyDefaults].
+// It is meant to be called during the initialization of the program.
+func RegisterTypeParser(t reflect.Type, fn func(string) (reflect.Value, error)) {
+	instance.RegisterTypeParser(t, fn)
+}
+
 // Defaulter defines an interface for setting default values based on kind.
 type Defaulter interface {
 	// Name returns the name of the defaulter, which is used for logging and error reporting purposes.
@@ -57,6 +233,16 @@ type Defaulter interface {
 	HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (callNext bool, set bool, err error)
 }
 
+// ClockedDefaulter is implemented by the defaulters that use the current time, such as [TimeDefaulter].
+// When a clock is configured with [WithClock], the registry uses copies of these defaulters with its clock, so that
+// the defaulters shared with other registries keep their own clocks.
+type ClockedDefaulter interface {
+	Defaulter
+
+	// WithClock returns a copy of the defaulter that uses the function to get the current time.
+	WithClock(now func() time.Time) Defaulter
+}
+
 // DefaulterWithPrecedence is a wrapper for Defaulter with a precedence.
 type DefaulterWithPrecedence struct {
 	// Defaulter is the defaulter to be used.
@@ -66,26 +252,174 @@ type DefaulterWithPrecedence struct {
 	Precedence int
 }
 
+// valueGenerator generates the default value of a field, whose extracted default value starts with the prefix the
+// generator is registered with. The prefix is trimmed before the default value is passed to the generator as arg.
+//
+// The returned string is passed to the defaulters as if it was written in the tag.
+//
+// The errors wrapping [ErrInvalidDefaultValue] make the next alternative of [WithAlternativeSeparator] be tried, e.g.
+// for a missing entry. The other errors stop applying the default value of the field.
+type valueGenerator func(path string, field reflect.StructField, arg string) (string, error)
+
+// valueGeneratorWithPrefix is a wrapper for valueGenerator with the prefix it is registered with.
+type valueGeneratorWithPrefix struct {
+	// prefix is the prefix of the default values the generator is called for.
+	prefix string
+
+	// generator is the generator to be used.
+	generator valueGenerator
+}
+
+// StructFinalizer is called with each struct value after its fields are defaulted, including the fields of its
+// nested structs. It can be used to normalize or cross-check the fields, or to derive computed fields.
+// The path is the path of the struct, e.g. `pkg.(Config).Server`.
+type StructFinalizer func(value reflect.Value, path string) error
+
+// UnknownKindHandler is called for the fields with a default value, whose kind no registered defaulter handles.
+// The value is the default value after resolving the templates, constants and generators.
+// The handler is expected to set the fieldValue, or return an error.
+type UnknownKindHandler func(field reflect.StructField, value string, fieldValue reflect.Value) error
+
+// DefaultValueValidator validates a default value after the templates and the macros are expanded, and before the
+// references and the generators are resolved. Returning an error aborts applying the defaults.
+type DefaultValueValidator func(path string, field reflect.StructField, value string) error
+
 // DefaulterRegistry defines an interface for managing defaulters.
 type DefaulterRegistry interface {
 	Register(precedence int, defaulter Defaulter) DefaulterRegistry
 	ApplyDefaults(obj interface{}) error
 }
 
+// ExtendedDefaulterRegistry is a DefaulterRegistry with the methods added after it. They are kept out of
+// DefaulterRegistry, so that its existing implementations keep compiling.
+//
+// The String method returns a human-readable description of the configuration of the registry, which is useful for
+// debugging why defaults are not applied.
+type ExtendedDefaulterRegistry interface {
+	DefaulterRegistry
+	fmt.Stringer
+	RegisterForPath(path string, precedence int, defaulter Defaulter) ExtendedDefaulterRegistry
+	RegisterConstants(constants map[string]string) ExtendedDefaulterRegistry
+	RegisterMacro(name string, value string) ExtendedDefaulterRegistry
+	RegisterEnum(names interface{}) ExtendedDefaulterRegistry
+	RegisterInterfaceType(iface interface{}, discriminator string, concrete interface{}) ExtendedDefaulterRegistry
+	RegisterFunc(name string, fn interface{}) ExtendedDefaulterRegistry
+	RegisterTypeParser(t reflect.Type, fn func(string) (reflect.Value, error)) ExtendedDefaulterRegistry
+	ApplyDefaultsWithData(obj interface{}, data any) error
+	ApplyDefaultsForGroup(obj interface{}, group string) error
+	WithDefaults(obj interface{}) (interface{}, error)
+	ApplyDefaultsStream(ctx context.Context, objs <-chan interface{}, results chan<- error) error
+	ApplyDefaultsWithTrace(obj interface{}) (Trace, error)
+	ApplyDefaultsWithReport(obj interface{}) (Report, error)
+	ApplyDefaultsWithPrototype(obj interface{}, prototype interface{}) error
+	ApplyDefaultsStrictComplete(obj interface{}) error
+	ApplyDefaultsFromJSONTree(obj interface{}, tree map[string]any) error
+	Validate(t reflect.Type) error
+	ValidateTypes(types ...reflect.Type) error
+}
+
 // defaulterRegistry manages registered defaulters for different kinds.
 type defaulterRegistry struct {
 	extractor  DefaultExtractor
 	defaulters map[reflect.Kind][]DefaulterWithPrecedence
 
+	// pathDefaulters are the defaulters registered for the fields at the paths, relative to the root.
+	pathDefaulters map[string][]DefaulterWithPrecedence
+
+	// generators are called in the order they are registered, the first one with a matching prefix wins.
+	generators []valueGeneratorWithPrefix
+
+	// constants are the named values that can be referenced with the ConstantPrefix in default values.
+	constants map[string]string
+
+	// macros are expanded wherever they are used with the MacroPrefix in default values.
+	macros map[string]string
+
+	// enums is registered on the first call to RegisterEnum.
+	enums *EnumDefaulter
+
+	// interfaces is registered on the first call to RegisterInterfaceType.
+	interfaces *InterfaceDefaulter
+
+	// funcs is registered on the first call to RegisterFunc.
+	funcs *FuncDefaulter
+
+	// typeParsers is registered on the first call to RegisterTypeParser, and for the kinds of the types added later.
+	typeParsers *TypeParserDefaulter
+
+	// typeFuncs return the default values of the fields of the types. See WithTypeDefaulterFunc.
+	typeFuncs map[reflect.Type]TypeDefaulterFunc
+
 	// IgnoreCannotSet is a flag to ignore fields that cannot be set.
 	ignoreCannotSet bool
+
+	// errorOnStructDefaultTag is a flag to return an error for struct fields with a default value that no defaulter sets.
+	errorOnStructDefaultTag bool
+
+	// validator validates the resolved default values, if set.
+	validator DefaultValueValidator
+
+	// templates is a flag to evaluate the default values as templates.
+	templates bool
+
+	// hasDefaultsCache caches whether the struct types have fields with default values. See hasDefaults.
+	hasDefaultsCache sync.Map
+
+	// disallowedKinds are the kinds that are never defaulted.
+	disallowedKinds map[reflect.Kind]bool
+
+	// clock returns the current time for the time-based defaults. time.Now is used if not set.
+	clock func() time.Time
+
+	// applyToEmptyCollections is a flag to apply the defaults to empty slices and maps, in addition to nil ones.
+	applyToEmptyCollections bool
+
+	// collectionSeparator separates the items of the slices and the pairs of the maps, if set.
+	collectionSeparator string
+
+	// overwriteExisting is a flag to apply the defaults to the non-zero fields as well.
+	overwriteExisting bool
+
+	// defaultNilOnly is a flag to default only the nil fields: pointers, slices, maps and interfaces.
+	defaultNilOnly bool
+
+	// neverAllocateCollections is a flag to leave the slices and the maps as they are, even if they are nil.
+	neverAllocateCollections bool
+
+	// alternativeSeparator separates the alternatives in the default values, if set.
+	alternativeSeparator string
+
+	// rootLabel is prepended to the root of the paths, if set.
+	rootLabel string
+
+	// skipPredicates return true for the fields that are not visited.
+	skipPredicates []SkipFieldPredicate
+
+	// finalizers are called after the fields of each struct are defaulted.
+	finalizers []StructFinalizer
+
+	// strictCompleteKinds are the kinds of the fields checked by ApplyDefaultsStrictComplete, in addition to the
+	// scalar kinds and the structs.
+	strictCompleteKinds map[reflect.Kind]bool
+
+	// unknownKindHandler is called for the fields of kinds without defaulters, instead of returning an error.
+	unknownKindHandler UnknownKindHandler
+
+	// collectAllErrors is a flag to continue with the remaining fields when a field fails, and return all errors.
+	collectAllErrors bool
+
+	// metrics receives the metrics of the defaulters, if set.
+	metrics MetricsSink
+
+	// maxFields is the maximum number of fields to visit in a single ApplyDefaults call. 0 means no limit.
+	maxFields int
 }
 
 // compile-time check for interface implementation.
-var _ DefaulterRegistry = &defaulterRegistry{}
+var _ ExtendedDefaulterRegistry = &defaulterRegistry{}
 
 // NewDefaulterRegistry creates a new defaulterRegistry with optional configurations.
-func NewDefaulterRegistry(options ...DefaulterRegistryOption) DefaulterRegistry {
+func NewDefaulterRegistry(options ...DefaulterRegistryOption) ExtendedDefaulterRegistry {
 	dr := &defaulterRegistry{
 		extractor:  nil,
 		defaulters: make(map[reflect.Kind][]DefaulterWithPrecedence),
@@ -114,6 +448,7 @@ func sortDefaulters(dwps []DefaulterWithPrecedence) {
 // The defaulters are sorted by precedence and called in that order.
 // If a defaulter denotes that the next defaulter should not be called, the process will stop.
 func (r *defaulterRegistry) Register(precedence int, defaulter Defaulter) DefaulterRegistry {
+	defaulter = r.withClock(defaulter)
 	for _, kind := range defaulter.HandledKinds() {
 		r.defaulters[kind] = append(r.defaulters[kind], DefaulterWithPrecedence{Defaulter: defaulter, Precedence: precedence})
 		// sort
@@ -122,6 +457,147 @@ func (r *defaulterRegistry) Register(precedence int, defaulter Defaulter) Defaul
 	return r
 }
 
+// RegisterForPath adds a defaulter to the registry, which is only called for the field at the path, e.g.
+// `<root>.Server.Port`. The root of the path is ignored, so `Server.Port` matches the same field of any root type.
+//
+// The defaulters registered for a path are called before the ones registered for the kinds, in the order of their
+// precedences, for the kinds the defaulter handles. If a path-scoped defaulter denotes that the next defaulter should
+// not be called, the defaulters registered for the kinds are not called either.
+//
+//nolint:lll
+func (r *defaulterRegistry) RegisterForPath(path string, precedence int, defaulter Defaulter) ExtendedDefaulterRegistry {
+	defaulter = r.withClock(defaulter)
+	if r.pathDefaulters == nil {
+		r.pathDefaulters = make(map[string][]DefaulterWithPrecedence)
+	}
+	path = relativePath(path)
+	dwp := DefaulterWithPrecedence{Defaulter: defaulter, Precedence: precedence}
+	r.pathDefaulters[path] = append(r.pathDefaulters[path], dwp)
+	sortDefaulters(r.pathDefaulters[path])
+	return r
+}
+
+// defaultersFor returns the defaulters to call for a field of the kind at the path: the ones registered for the path
+// that handle the kind, followed by the ones registered for the kind. It returns false if there are none.
+func (r *defaulterRegistry) defaultersFor(kind reflect.Kind, fieldPath string) ([]DefaulterWithPrecedence, bool) {
+	defaulters, ok := r.defaulters[kind]
+	scoped := r.pathDefaulters[relativePath(fieldPath)]
+	if len(scoped) == 0 {
+		return defaulters, ok
+	}
+
+	var result []DefaulterWithPrecedence
+	for _, dwp := range scoped {
+		if slices.Contains(dwp.Defaulter.HandledKinds(), kind) {
+			result = append(result, dwp)
+		}
+	}
+	if len(result) == 0 {
+		return defaulters, ok
+	}
+	return append(result, defaulters...), true
+}
+
+// RegisterConstants registers named values that can be referenced in default values with the ConstantPrefix.
+// For example, after registering `{"DefaultPort": "8080"}`, `default:"const:DefaultPort"` yields "8080".
+//
+// Go reflection cannot read constants by name, so the values need to be registered explicitly.
+// Registering a name again overwrites the previous value.
+func (r *defaulterRegistry) RegisterConstants(constants map[string]string) ExtendedDefaulterRegistry {
+	if r.constants == nil {
+		r.constants = make(map[string]string, len(constants))
+		r.generators = append(r.generators, valueGeneratorWithPrefix{prefix: ConstantPrefix, generator: r.lookupConstant})
+	}
+	for name, value := range constants {
+		r.constants[name] = value
+	}
+	return r
+}
+
+func (r *defaulterRegistry) lookupConstant(_ string, _ reflect.StructField, name string) (string, error) {
+	value, ok := r.constants[name]
+	if !ok {
+		return "", newInvalidValueError("unknown constant '%s'", name)
+	}
+	return value, nil
+}
+
+// RegisterEnum registers the names of the values of an enum type, so that the fields of that type and the slices of
+// that type can be defaulted by name. The names must be a map with string keys and the enum type as the value type:
+//
+//	reg.RegisterEnum(map[string]Weekday{"Monday": Monday, "Tuesday": Tuesday})
+//
+// See [EnumDefaulter] for more information. It panics if the names are not such a map.
+func (r *defaulterRegistry) RegisterEnum(names interface{}) ExtendedDefaulterRegistry {
+	if r.enums == nil {
+		r.enums = NewEnumDefaulter()
+		r.Register(PrecedenceEnumDefaulter, r.enums)
+	}
+	r.enums.Add(names)
+	return r
+}
+
+// RegisterInterfaceType registers a concrete type of an interface type with a discriminator, so that the fields of
+// the interface type can be defaulted from JSON objects with the discriminator in the "type" key:
+//
+//	reg.RegisterInterfaceType((*Step)(nil), "http", &HTTPStep{})
+//
+// See [InterfaceDefaulter] for more information. It panics if iface is not a pointer to an interface or if the
+// concrete type doesn't implement the interface.
+//
+//nolint:lll
+func (r *defaulterRegistry) RegisterInterfaceType(iface interface{}, discriminator string, concrete interface{}) ExtendedDefaulterRegistry {
+	if r.interfaces == nil {
+		r.interfaces = NewInterfaceDefaulter()
+		r.Register(PrecedenceOtherDefaulter, r.interfaces)
+	}
+	r.interfaces.Add(iface, discriminator, concrete)
+	return r
+}
+
+// RegisterFunc registers a function with a name, so that the nil function fields of a matching type can be defaulted
+// by the name, e.g. `default:"noop"` after registering:
+//
+//	reg.RegisterFunc("noop", func() {})
+//
+// See [FuncDefaulter] for more information. It panics if fn is not a non-nil function.
+func (r *defaulterRegistry) RegisterFunc(name string, fn interface{}) ExtendedDefaulterRegistry {
+	if r.funcs == nil {
+		r.funcs = NewFuncDefaulter()
+		r.Register(PrecedenceOtherDefaulter, r.funcs)
+	}
+	r.funcs.Add(name, fn)
+	return r
+}
+
+// RegisterTypeParser registers a parser for the fields of a type, and the items of the slices of that type.
+// The parsers are called before the defaulters registered for the kind of the type, e.g. to wire
+// `decimal.NewFromString` for `decimal.Decimal` fields, which are structs:
+//
+//	reg.RegisterTypeParser(reflect.TypeFor[decimal.Decimal](), func(s string) (reflect.Value, error) {
+//		d, err := decimal.NewFromString(s)
+//		return reflect.ValueOf(d), err
+//	})
+//
+// See [TypeParserDefaulter] for more information. It panics if t is nil or a pointer type, or if fn is nil.
+//
+//nolint:lll
+func (r *defaulterRegistry) RegisterTypeParser(t reflect.Type, fn func(string) (reflect.Value, error)) ExtendedDefaulterRegistry {
+	if r.typeParsers == nil {
+		r.typeParsers = NewTypeParserDefaulter()
+	}
+	handled := r.typeParsers.HandledKinds()
+	r.typeParsers.Add(t, fn)
+	if !slices.Contains(handled, t.Kind()) {
+		// the defaulter is registered only for the kinds of the added types, so that the fields of other kinds are
+		// still reported as not supported
+		dwp := DefaulterWithPrecedence{Defaulter: r.typeParsers, Precedence: PrecedenceTypeParserDefaulter}
+		r.defaulters[t.Kind()] = append(r.defaulters[t.Kind()], dwp)
+		sortDefaulters(r.defaulters[t.Kind()])
+	}
+	return r
+}
+
 // DefaulterRegistryOption represents functional options for configuring defaulterRegistry.
 type DefaulterRegistryOption func(r *defaulterRegistry)
 
@@ -132,8 +608,9 @@ func WithBasicDefaulters() DefaulterRegistryOption {
 		r.Register(PrecedencePrimitiveDefaulter, &IntDefaulter{})
 		r.Register(PrecedencePrimitiveDefaulter, &UintDefaulter{})
 		r.Register(PrecedencePrimitiveDefaulter, &FloatDefaulter{})
-		r.Register(PrecedencePrimitiveDefaulter, &SliceDefaulter{})
-		r.Register(PrecedencePrimitiveDefaulter, &MapDefaulter{})
+		r.Register(PrecedencePrimitiveDefaulter, &SliceDefaulter{registry: r})
+		r.Register(PrecedencePrimitiveDefaulter, &MapDefaulter{registry: r})
+		r.Register(PrecedencePrimitiveDefaulter, &ArrayDefaulter{})
 		r.Register(PrecedencePrimitiveDefaulter, &StringDefaulter{})
 
 		// some additional defaulters for non-primitive types
@@ -141,6 +618,38 @@ func WithBasicDefaulters() DefaulterRegistryOption {
 		// should run after IntDefaulter as we want non-durations to be handled by IntDefaulter first
 		// - [DurationDefaulter] - precedence 2000.
 		r.Register(PrecedenceOtherDefaulter, &DurationDefaulter{})
+
+		// bytes.Buffer is a struct, it is initialized with the default value instead of being recursed into.
+		// - [BufferDefaulter] - precedence 2000.
+		r.Register(PrecedenceOtherDefaulter, &BufferDefaulter{})
+
+		// netip.Addr and netip.Prefix are structs as well, they are parsed instead of being recursed into.
+		// - [NetIPDefaulter] - precedence 2000.
+		r.Register(PrecedenceOtherDefaulter, &NetIPDefaulter{})
+
+		// time.Time is a struct with unexported fields, it is parsed instead of being recursed into.
+		// A TimeDefaulter with another layout can be registered in addition, it is called after this one.
+		// - [TimeDefaulter] - precedence 2000.
+		r.Register(PrecedenceOtherDefaulter, &TimeDefaulter{})
+
+		// the types implementing encoding.TextUnmarshaler, e.g. net.IP, are unmarshaled instead of being parsed as
+		// their underlying kinds, or recursed into.
+		// - [TextUnmarshalerDefaulter] - precedence 600.
+		r.Register(PrecedenceTextUnmarshalerDefaulter, &TextUnmarshalerDefaulter{})
+
+		// the types implementing json.Unmarshaler are unmarshaled from JSON literals.
+		// - [JSONUnmarshalerDefaulter] - precedence 700.
+		r.Register(PrecedenceJSONUnmarshalerDefaulter, &JSONUnmarshalerDefaulter{})
+	}
+}
+
+// WithDefaulters registers the defaulters with their precedences, as if [DefaulterRegistry.Register] is called for
+// each of them. The defaulters with the same precedence are called in the given order.
+func WithDefaulters(defaulters ...DefaulterWithPrecedence) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		for _, d := range defaulters {
+			r.Register(d.Precedence, d.Defaulter)
+		}
 	}
 }
 
@@ -161,139 +670,1217 @@ func WithIgnoreCannotSet(ignore bool) DefaulterRegistryOption {
 	}
 }
 
-// ApplyDefaults applies default values to the struct.
-func (r *defaulterRegistry) ApplyDefaults(obj interface{}) error {
-	val := reflect.ValueOf(obj)
-	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
-		return errors.New("object must be a pointer to a struct")
+// WithErrorOnStructDefaultTag sets the flag to return an error for struct fields (non-pointer) with a default value,
+// that no defaulter for the struct kind sets.
+//
+// Struct fields are recursed into and their default value is ignored otherwise, which may hide mistakes such as a
+// default tag put on the struct field instead of the fields of the struct.
+func WithErrorOnStructDefaultTag(errorOnStructDefaultTag bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.errorOnStructDefaultTag = errorOnStructDefaultTag
 	}
+}
 
-	// check if the type definition allows cycles
-	if detectPotentialCycles(val.Elem().Type(), make(map[reflect.Type]bool)) {
-		return errors.New("type definition must not have cycles")
+// WithDefaultValueValidator sets a validator that is called for every default value after the templates and the
+// macros are expanded, and before the references and the generators are resolved. This is useful for enforcing a
+// policy on the default values as written, such as not having secrets inline. The validator doesn't see the values of
+// the secrets, the generated values or the values of the referenced fields, and the generators are not called for the
+// rejected default values.
+//
+// With [WithAlternativeSeparator], each alternative is validated before it is tried. A rejected alternative stops
+// applying the default value of the field, the next alternatives are not tried.
+//
+// The errors returned by the validator are wrapped as [ErrInvalidDefaultValue].
+func WithDefaultValueValidator(validator DefaultValueValidator) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.validator = validator
 	}
+}
 
-	var path string
-	typeName := val.Elem().Type().Name()
-	if typeName == "" {
-		path = "<root>"
-	} else {
-		path = fmt.Sprintf("%s.(%s)", val.Elem().Type().PkgPath(), typeName)
+// WithTemplates sets the flag to evaluate the default values containing "{{" as [text/template] templates, before
+// they are passed to the generators and the defaulters.
+//
+// The templates are executed with the data passed to [ExtendedDefaulterRegistry.ApplyDefaultsWithData], or with nil
+// data when [DefaulterRegistry.ApplyDefaults] is used. For example, `default:"{{.Env.REGION}}-bucket"` yields
+// "eu-bucket" with the data `map[string]any{"Env": map[string]string{"REGION": "eu"}}`.
+//
+// Missing keys and other template errors are wrapped as [ErrInvalidDefaultValue].
+func WithTemplates(templates bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.templates = templates
 	}
-
-	return r.DoApplyDefaults(val.Elem(), path)
 }
 
-// TODO: can we extract a function to call in the for loop?
+// WithStructFinalizer adds a finalizer that is called once per struct, after the fields of the struct are defaulted.
+// The nested structs are finalized before the structs that contain them. The finalizers are called in the order
+// they are added.
 //
-//nolint:gocognit,funlen 	// we can't extract to a function/method because of the error handling
-func (r *defaulterRegistry) DoApplyDefaults(value reflect.Value, path string) error {
-	if r.extractor == nil {
-		return errors.New("default extractor is not set")
+// Unlike the defaulters, the finalizers are configured in the registry and not on the field level.
+func WithStructFinalizer(finalizer StructFinalizer) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.finalizers = append(r.finalizers, finalizer)
 	}
+}
 
-	if len(r.defaulters) == 0 {
-		return errors.New("no defaulters are registered")
+// WithUnknownKindHandler sets a handler that is called for the fields with a default value, whose kind no registered
+// defaulter handles. Without a handler, such fields result in an [ErrNotSupported] error.
+//
+// It is meant for last-resort logic. The kinds that are disallowed with [WithDisallowedKinds] are not passed to the
+// handler. Errors returned by the handler are reported as [ErrInvalidDefaultValue] errors of the field.
+func WithUnknownKindHandler(handler UnknownKindHandler) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.unknownKindHandler = handler
 	}
+}
 
-	// dereference pointer
-	if value.Kind() == reflect.Ptr {
-		value = value.Elem()
+// WithStrictCompleteKinds adds kinds to the ones checked by [ExtendedDefaulterRegistry.ApplyDefaultsStrictComplete],
+// e.g. [reflect.Ptr] or [reflect.Slice]. The pointers, collections, interfaces, channels and functions are not
+// checked by default, as they are commonly left nil on purpose.
+func WithStrictCompleteKinds(kinds ...reflect.Kind) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		if r.strictCompleteKinds == nil {
+			r.strictCompleteKinds = make(map[reflect.Kind]bool, len(kinds))
+		}
+		for _, kind := range kinds {
+			r.strictCompleteKinds[kind] = true
+		}
 	}
+}
 
-	// we only handle structs
-	if value.Kind() != reflect.Struct {
-		return nil
+// WithDisallowedKinds disallows defaulting the fields of the given kinds, regardless of the defaulters registered.
+// A field of a disallowed kind, or a pointer to a disallowed kind, results in an [ErrNotSupported] error when it has a
+// default value and is skipped otherwise. The fields of disallowed struct kinds are not recursed into.
+func WithDisallowedKinds(kinds ...reflect.Kind) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		if r.disallowedKinds == nil {
+			r.disallowedKinds = make(map[reflect.Kind]bool, len(kinds))
+		}
+		for _, kind := range kinds {
+			r.disallowedKinds[kind] = true
+		}
 	}
+}
 
-	fieldType := value.Type()
-	for i := range value.NumField() {
-		field := fieldType.Field(i)
-		fieldValue := value.Field(i)
-
-		// Handle nested struct (including pointers to structs)
-		if fieldValue.Kind() == reflect.Struct {
-			if err := r.DoApplyDefaults(fieldValue, addFieldToPath(path, field)); err != nil {
-				return err
-			}
-			continue
-		} else if fieldValue.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
-			// Initialize pointer to struct if nil
-			if fieldValue.IsNil() {
-				fieldValue.Set(reflect.New(field.Type.Elem()))
+// WithClock sets the clock that all the time-based dynamic defaults use, such as `default:"now+24h"` with
+// [TimeDefaulter]. [time.Now] is used if not set.
+//
+// The registry uses the clock for all the defaulters implementing [ClockedDefaulter], including the ones registered
+// after this option, replacing their own clocks. The defaulters themselves are not changed, the registry uses copies
+// of them. This makes the tests deterministic and enables frozen-time scenarios.
+func WithClock(now func() time.Time) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.clock = now
+		for _, defaulters := range r.defaulters {
+			for i := range defaulters {
+				defaulters[i].Defaulter = r.withClock(defaulters[i].Defaulter)
 			}
-			if err := r.DoApplyDefaults(fieldValue.Elem(), addFieldToPath(path, field)); err != nil {
-				return err
+		}
+		for _, defaulters := range r.pathDefaulters {
+			for i := range defaulters {
+				defaulters[i].Defaulter = r.withClock(defaulters[i].Defaulter)
 			}
-			continue
 		}
+	}
+}
 
-		if fieldValue.IsValid() && !fieldValue.IsZero() {
-			// we do not overwrite non-zero values
-			continue
-		}
+// withClock returns a copy of the defaulter with the clock of the registry, if the defaulter uses a clock and the
+// registry has one. Otherwise, the defaulter is returned as is.
+func (r *defaulterRegistry) withClock(defaulter Defaulter) Defaulter {
+	if clocked, ok := defaulter.(ClockedDefaulter); ok && r.clock != nil {
+		return clocked.WithClock(r.clock)
+	}
+	return defaulter
+}
 
-		defaultStr, found, err := r.extractor.ExtractDefault(field)
-		if err != nil {
-			return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
-		}
-		if !found {
-			continue
-		}
+// WithOverwriteExisting sets the flag to apply the default values to the fields that are already non-zero as well,
+// e.g. to reset the fields that are loaded from another source. By default, the non-zero fields are kept.
+//
+// The slices and maps with a default value are replaced entirely when this is on, their existing items are dropped,
+// not merged. The values pointed by the non-nil pointer fields are overwritten in place. The fields of the existing
+// nested structs, and of the existing elements of the slices, arrays and maps of structs, are overwritten as well.
+func WithOverwriteExisting(overwrite bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.overwriteExisting = overwrite
+	}
+}
 
-		// we don't allow pointers to pointers
-		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Ptr {
-			return NewError(nil, ErrNotSupported, path, field, "pointer to pointer is not allowed")
-		}
+// WithApplyToEmptyCollections sets the flag to apply the default values to empty, but non-nil, slices and maps.
+// By default, only nil slices and maps are defaulted, as the empty ones are not zero values.
+// Populated slices and maps are never overwritten.
+func WithApplyToEmptyCollections(apply bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.applyToEmptyCollections = apply
+	}
+}
 
-		kind := field.Type.Kind()
-		if kind == reflect.Ptr {
-			kind = field.Type.Elem().Kind()
-		}
-		//nolint:nestif // can't extract to a function/method because of the error handling
-		if defaulters, ok := r.defaulters[kind]; ok {
-			if !fieldValue.CanSet() {
-				if r.ignoreCannotSet {
-					continue
-				}
-				return NewError(nil, ErrCannotSetField, path, field, "cannot set field")
-			}
+// WithCollectionSeparator sets the separator of the items of the slices and the pairs of the maps in the default
+// values, e.g. "," for `default:"a,b,c"` or `default:"a:1,b:2"`. The items are trimmed and a trailing separator is
+// tolerated, e.g. `default:"a,b,"` yields [a b]. The separator can be escaped with a backslash to be kept in an item,
+// e.g. `default:"a\,b,c"` yields ["a,b" "c"]. By default, the items are separated by whitespace.
+//
+// The separator of the extractor must be different, e.g. `NewDefaultzExtractor("default", "", ";")` for ",".
+func WithCollectionSeparator(separator string) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.collectionSeparator = separator
+	}
+}
 
-			var result *multierror.Error
-			var somethingSet bool
-			for _, defaulterWithPrecedence := range defaulters {
-				defaulter := defaulterWithPrecedence.Defaulter
-
-				var callNext bool
-				var set bool
-				callNext, set, err = defaulter.HandleField(defaultStr, path, field, fieldValue)
-				// err is always nil for the existing defaulters. May not be nil for custom defaulters.
-				if err != nil {
-					result = multierror.Append(result, err)
-					// we continue to the next defaulter
-				}
-				if set {
-					somethingSet = true
-				}
-				if !callNext {
-					break
-				}
-			}
-			// if there's nothing set and there are errors, return an error
-			if !somethingSet && result != nil {
-				if result.Len() == 1 {
-					return fmt.Errorf("failed to apply default value : %w", result.Errors[0])
-				}
-				return fmt.Errorf("failed to apply default value: %w", result)
-			}
-		} else {
-			return NewError(nil, ErrNotSupported, path, field, fmt.Sprintf("no defaulters found for kind '%s'", kind))
-		}
+// WithDefaultNilOnly sets the flag to default only the nil fields: pointers, slices, maps and interfaces.
+// The zero values of the other kinds, such as 0 for an int or false for a bool, are treated as explicit values and
+// they are never defaulted. Use pointers, e.g. *int, for the scalar fields that should be defaulted.
+// The nested structs are still recursed into.
+func WithDefaultNilOnly(nilOnly bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.defaultNilOnly = nilOnly
 	}
+}
 
-	return nil
+// WithNeverAllocateCollections sets the flag to never default the slices and the maps, and the pointers to them, so
+// that the nil ones are kept nil regardless of their default values. The existing elements of the slices and the maps
+// of structs are still recursed into.
+func WithNeverAllocateCollections(never bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.neverAllocateCollections = never
+	}
 }
 
-func addFieldToPath(path string, field reflect.StructField) string {
-	return path + "." + field.Name
+// WithAlternativeSeparator sets the separator of the alternatives in the default values, e.g. "||" for
+// `default:"primary || secondary"`. The alternatives are trimmed and tried in order, and the first one that the
+// defaulters can apply without errors wins. For example, `default:"abc || 42"` on an int field yields 42.
+//
+// Only the invalid values, the errors wrapping [ErrInvalidDefaultValue], [ErrInvalidDefaultValueItem] or
+// [ErrInvalidDefaultValueKey], move on to the next alternative. These include the unknown constants and the missing
+// entries of [WithEnvFile]. The other errors, e.g. the ones of the validator, the templates or a secret provider, stop
+// applying the default value of the field.
+//
+// The alternatives are not split if the separator is not set, which is the default.
+func WithAlternativeSeparator(separator string) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.alternativeSeparator = separator
+	}
+}
+
+// WithRootLabel sets a label that is prepended to the root of the paths in errors, e.g. `mylabel:<root>.Field`
+// instead of `<root>.Field`. This is useful to tell the errors of different structs apart, especially anonymous ones,
+// when applying defaults to many structs.
+//
+// The root of the paths of named types already includes the package path and the type name,
+// e.g. `mylabel:github.com/foo/bar.(Config).Field`.
+func WithRootLabel(label string) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.rootLabel = label
+	}
+}
+
+// WithCollectAllErrors sets the flag to continue processing the remaining fields when a field fails, instead of
+// stopping at the first error. This includes the fields in the sibling nested structs and in the other branches of
+// the struct tree.
+//
+// All the errors are returned at the end, combined in a [*multierror.Error]. Each of them still carries the path and
+// the field information, so that all the invalid default values can be seen in one pass.
+func WithCollectAllErrors(collectAllErrors bool) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.collectAllErrors = collectAllErrors
+	}
+}
+
+// WithMaxFields sets the maximum number of fields visited in a single ApplyDefaults call, including the fields of the
+// nested structs. ApplyDefaults aborts with [ErrMaxFieldsExceeded] once the limit is exceeded.
+// This is a safety valve against pathological structs, such as generated code with thousands of fields.
+//
+// 0 means no limit, which is the default.
+func WithMaxFields(n int) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.maxFields = n
+	}
+}
+
+// WithStructLiterals registers a [StructLiteralDefaulter], which sets struct fields from `key=value` pairs in the
+// default value, e.g. `default:"max=3,backoff=1s"`. The fields of the struct are set with the defaulters of the
+// registry at the time the defaults are applied, so the order of this option and the ones registering the defaulters
+// doesn't matter.
+//
+// It is registered with the [PrecedenceOtherDefaulter].
+func WithStructLiterals() DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.Register(PrecedenceOtherDefaulter, &StructLiteralDefaulter{registry: r})
+	}
+}
+
+// ApplyDefaults applies default values to the struct.
+func (r *defaulterRegistry) ApplyDefaults(obj interface{}) error {
+	return r.apply(obj, &applyState{})
+}
+
+// ApplyDefaultsWithData applies default values to the struct, evaluating the default values as templates with the
+// given data. See [WithTemplates] for more information.
+func (r *defaulterRegistry) ApplyDefaultsWithData(obj interface{}, data any) error {
+	return r.apply(obj, &applyState{data: data})
+}
+
+// apply applies default values to the struct with the given state.
+func (r *defaulterRegistry) apply(obj interface{}, state *applyState) error {
+	val, err := checkRoot(obj)
+	if err != nil {
+		return err
+	}
+
+	err = r.applyDefaults(state, val.Elem(), r.rootPath(val.Elem().Type()))
+	if err == nil {
+		err = state.errors.ErrorOrNil()
+	}
+	if err != nil {
+		state.rollback()
+	}
+	return err
+}
+
+// rootPath returns the path of the root struct, e.g. `pkg.(Config)`, or `<root>` for anonymous structs.
+func (r *defaulterRegistry) rootPath(t reflect.Type) string {
+	path := typePath(t)
+	if r.rootLabel != "" {
+		path = r.rootLabel + ":" + path
+	}
+	return path
+}
+
+// applyState holds the state of a single ApplyDefaults call.
+type applyState struct {
+	// fieldsVisited is the number of the fields visited so far.
+	fieldsVisited int
+
+	// data is the data the default values are evaluated with as templates.
+	data any
+
+	// errors are the collected field errors, when collecting all errors.
+	errors *multierror.Error
+
+	// group is the group of the fields to apply the defaults of, if set. The changes are journaled in this case.
+	group string
+
+	// groupDepth is the number of the struct fields in the group the current field is nested in.
+	groupDepth int
+
+	// journal holds the original values of the changed fields, to be able to roll back the changes.
+	journal []journalEntry
+
+	// trace is the decision log of the call, if tracing.
+	trace *Trace
+
+	// root is the root struct, the references in the default values are resolved against.
+	root reflect.Value
+
+	// rootPath is the path of the root struct.
+	rootPath string
+
+	// resolving are the paths of the fields whose references are being resolved, to detect the cycles.
+	resolving []string
+
+	// validating is a flag to check the default values without resolving the references and calling the generators,
+	// which may have side effects, e.g. reading secrets. The fields with such default values are left as they are.
+	validating bool
+}
+
+// journalEntry is the original value of a changed field, or of a changed map value if key is valid.
+type journalEntry struct {
+	value    reflect.Value
+	key      reflect.Value
+	original reflect.Value
+}
+
+// record saves the original value of the field before it is changed, when applying the defaults of a group.
+func (s *applyState) record(value reflect.Value) {
+	if s.group == "" {
+		return
+	}
+	original := reflect.New(value.Type()).Elem()
+	original.Set(value)
+	s.journal = append(s.journal, journalEntry{value: value, original: original})
+}
+
+// recordMapIndex saves the original value of the key of the map before it is changed, when applying the defaults of a
+// group.
+func (s *applyState) recordMapIndex(m reflect.Value, key reflect.Value) {
+	if s.group == "" {
+		return
+	}
+	s.journal = append(s.journal, journalEntry{value: m, key: key, original: m.MapIndex(key)})
+}
+
+// rollback restores the original values of the changed fields, in reverse order.
+func (s *applyState) rollback() {
+	for i := len(s.journal) - 1; i >= 0; i-- {
+		entry := s.journal[i]
+		if entry.key.IsValid() {
+			entry.value.SetMapIndex(entry.key, entry.original)
+			continue
+		}
+		entry.value.Set(entry.original)
+	}
+	s.journal = nil
+}
+
+// inGroup returns true if the field is in the group being applied, or if no group is being applied.
+func (s *applyState) inGroup() bool {
+	return s.group == "" || s.groupDepth > 0
+}
+
+// hasGroup returns true if the group tag of the field contains the group.
+func hasGroup(field reflect.StructField, group string) bool {
+	for _, g := range strings.Split(field.Tag.Get("group"), ",") {
+		if strings.TrimSpace(g) == group {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyDefaultsForGroup applies only the default values of the fields in the group, all or nothing.
+//
+// The fields are put in groups with the `group` tag, which is a comma-separated list of group names, e.g.
+// `group:"network,server"`. The fields of a nested struct are in the groups of the struct field as well.
+//
+// The nil struct pointers outside the group are allocated only if the group sets a field in the struct they point to.
+// If any field in the group fails, none of the changes are kept: the fields are restored to their original values,
+// including the nested struct pointers allocated during the call.
+func (r *defaulterRegistry) ApplyDefaultsForGroup(obj interface{}, group string) error {
+	if group == "" {
+		return errors.New("group must not be empty")
+	}
+	return r.apply(obj, &applyState{group: group})
+}
+
+// WithDefaults deep-copies the struct, applies default values to the copy and returns it, so that the struct
+// itself is never modified. The object must be a pointer to a struct and the result is a pointer of the same type.
+//
+// The fields that cannot be set with reflection, such as unexported fields, are copied shallowly.
+func (r *defaulterRegistry) WithDefaults(obj interface{}) (interface{}, error) {
+	result, err := deepCopy(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ApplyDefaults(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyDefaultsWithTrace applies default values to the struct like [DefaulterRegistry.ApplyDefaults], and returns the
+// decision log of the call: every field visited, whether it is set and by which defaulters, skipped and why, or failed.
+// The trace is returned with the error as well, up to the field that failed, to help finding out why.
+//
+// Tracing is heavier than applying the defaults, so it is meant for debugging and support, not for every call.
+func (r *defaulterRegistry) ApplyDefaultsWithTrace(obj interface{}) (Trace, error) {
+	trace := &Trace{}
+	err := r.apply(obj, &applyState{trace: trace})
+	return *trace, err
+}
+
+// ApplyDefaultsStream applies default values to the structs received from objs one by one, until objs is closed or
+// the context is done. The result of each struct, nil or the error of applying its defaults, is sent to results in
+// the order the structs are received, which can be used to track the progress.
+//
+// The structs are not materialized: each one is handled as soon as it is received and the caches of the registry,
+// such as the types without default values, are shared between them. An error of a struct doesn't stop the stream.
+//
+// It returns the error of the context if it is done before objs is closed, nil otherwise.
+// The results channel is closed when it returns.
+//
+//nolint:lll
+func (r *defaulterRegistry) ApplyDefaultsStream(ctx context.Context, objs <-chan interface{}, results chan<- error) error {
+	defer close(results)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case obj, ok := <-objs:
+			if !ok {
+				return nil
+			}
+			select {
+			case results <- r.ApplyDefaults(obj):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// DoApplyDefaults applies default values to the struct value, using the path as the prefix for the paths in errors.
+// With [WithCollectAllErrors], the errors of all the fields are returned combined in a [*multierror.Error].
+func (r *defaulterRegistry) DoApplyDefaults(value reflect.Value, path string) error {
+	state := &applyState{}
+	if err := r.applyDefaults(state, value, path); err != nil {
+		return err
+	}
+	return state.errors.ErrorOrNil()
+}
+
+// applyDefaults applies default values to the fields of the struct value, walking the struct tree with an
+// applyVisitor.
+func (r *defaulterRegistry) applyDefaults(state *applyState, value reflect.Value, path string) error {
+	if r.extractor == nil {
+		return errors.New("default extractor is not set")
+	}
+
+	if len(r.defaulters) == 0 {
+		return errors.New("no defaulters are registered")
+	}
+
+	// dereference pointer
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	// we only handle structs
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	state.root = value
+	state.rootPath = path
+
+	return walkStruct(applyVisitor{registry: r, state: state}, value, path)
+}
+
+// applyVisitor applies the default values to the fields visited.
+//
+// When collecting all errors, the field errors are added to the state instead of being returned, so that the
+// remaining fields, including the ones in the other branches of the struct tree, are still processed.
+type applyVisitor struct {
+	registry *defaulterRegistry
+	state    *applyState
+}
+
+//nolint:lll
+func (v applyVisitor) visitField(path string, parent reflect.Type, field reflect.StructField, fieldValue reflect.Value, walk func() error) error {
+	r, state := v.registry, v.state
+
+	state.fieldsVisited++
+	if r.maxFields > 0 && state.fieldsVisited > r.maxFields {
+		return NewError(nil, ErrMaxFieldsExceeded, path, field, fmt.Sprintf("more than %d fields visited", r.maxFields))
+	}
+
+	entries := state.trace.len()
+	if err := r.applyField(state, path, parent, field, fieldValue, walk); err != nil {
+		if state.trace.len() == entries {
+			// the errors of the nested fields are traced by the nested fields
+			state.trace.add(path, field, TraceEntry{Outcome: TraceFailed, Error: err.Error()})
+		}
+		// exceeding the maximum number of fields always aborts
+		if !r.collectAllErrors || errors.Is(err, ErrMaxFieldsExceeded) {
+			return err
+		}
+		state.errors = multierror.Append(state.errors, err)
+	}
+	return nil
+}
+
+func (v applyVisitor) leaveStruct(path string, value reflect.Value) error {
+	for _, finalizer := range v.registry.finalizers {
+		if err := finalizer(value, path); err != nil {
+			err = fmt.Errorf("struct finalizer failed for path '%s': %w", path, err)
+			if !v.registry.collectAllErrors {
+				return err
+			}
+			v.state.errors = multierror.Append(v.state.errors, err)
+		}
+	}
+	return nil
+}
+
+func (v applyVisitor) setMapIndex(m reflect.Value, key reflect.Value, elem reflect.Value) {
+	// the defaults applied before an error are kept, like the ones of the other fields
+	v.state.recordMapIndex(m, key)
+	m.SetMapIndex(key, elem)
+}
+
+// applyField applies the default value to a single field of a struct, calling walk to recurse into the nested
+// structs.
+//
+//nolint:gocognit,funlen,lll	// this is the main logic, splitting it further would make it harder to follow
+func (r *defaulterRegistry) applyField(state *applyState, path string, parent reflect.Type, field reflect.StructField, fieldValue reflect.Value, walk func() error) error {
+	if r.isSkipped(field) {
+		return state.trace.skip(path, field, "skipped by a predicate")
+	}
+
+	if r.isDisallowed(field.Type) {
+		_, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+		if err != nil {
+			return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+		}
+		if found {
+			msg := fmt.Sprintf("defaulting kind '%s' is not allowed", field.Type.Kind())
+			return NewError(nil, ErrNotSupported, path, field, msg)
+		}
+		return state.trace.skip(path, field, "kind is disallowed")
+	}
+
+	if state.group != "" && hasGroup(field, state.group) {
+		// the nested fields are in the group of the struct field as well
+		state.groupDepth++
+		defer func() { state.groupDepth-- }()
+	}
+
+	if fn, ok := r.typeFunc(field); ok && state.inGroup() {
+		// the fields of the types with a function are not recursed into, nor passed to the defaulters
+		return r.applyTypeFunc(state, path, field, fieldValue, fn)
+	}
+
+	if (isSetType(field.Type) || isSetPtrType(field.Type)) && state.inGroup() {
+		// the Set fields are defaulted by their presence flag, not by their zero-ness
+		return r.applySet(state, path, field, fieldValue)
+	}
+
+	// Handle struct fields with a default value, which may be set by the defaulters for the struct kind
+	isStructPtr := fieldValue.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct
+	isDefaultableStruct := fieldValue.Kind() == reflect.Struct && !r.defaultNilOnly
+	if (isDefaultableStruct || isStructPtr) && state.inGroup() {
+		handled, err := r.applyStructDefault(state, path, parent, field, fieldValue)
+		if err != nil || handled {
+			return err
+		}
+	}
+
+	// Handle nested struct (including pointers to structs)
+	if fieldValue.Kind() == reflect.Struct {
+		state.trace.add(path, field, TraceEntry{Outcome: TraceRecursed})
+		return walk()
+	} else if isStructPtr {
+		// Initialize pointer to struct if nil
+		if fieldValue.IsNil() {
+			if !fieldValue.CanSet() {
+				// we cannot allocate unexported pointers, e.g. the location of a time.Time, which is only an error if
+				// there's something to default in the struct, like for the other fields that cannot be set
+				if r.ignoreCannotSet || !r.hasDefaults(field.Type.Elem(), addFieldToPath(path, field)) {
+					return state.trace.skip(path, field, "cannot allocate the pointer")
+				}
+				return NewError(nil, ErrCannotSetField, path, field, "cannot allocate the pointer")
+			}
+			if field.Anonymous && !r.hasDefaults(field.Type.Elem(), addFieldToPath(path, field)) {
+				// we don't allocate embedded structs, unless there's something to default in them
+				return state.trace.skip(path, field, "embedded struct without default values")
+			}
+			journaled := len(state.journal)
+			state.record(fieldValue)
+			fieldValue.Set(reflect.New(field.Type.Elem()))
+			if !state.inGroup() {
+				// outside the group, the pointer is kept only if the group sets something in the struct
+				state.trace.add(path, field, TraceEntry{Outcome: TraceRecursed})
+				err := walk()
+				if err == nil && len(state.journal) == journaled+1 {
+					fieldValue.SetZero()
+					state.journal = state.journal[:journaled]
+				}
+				return err
+			}
+		}
+		state.trace.add(path, field, TraceEntry{Outcome: TraceRecursed})
+		return walk()
+	} else if isStructCollection(fieldValue.Type()) && fieldValue.Len() > 0 {
+		// Handle existing elements of slices and arrays of structs, or pointers to structs, including inline
+		// anonymous structs, and of the collections of them, e.g. map[string][]Child. We don't allocate elements, so
+		// nil and empty slices are left to the defaulters below, and nil pointer elements are skipped.
+		state.trace.add(path, field, TraceEntry{Outcome: TraceRecursed})
+		return walk()
+	}
+
+	if !state.inGroup() {
+		// not in the group being applied
+		return state.trace.skip(path, field, "not in the group")
+	}
+
+	if r.defaultNilOnly && !isNillable(fieldValue.Kind()) {
+		// zero values are explicit values, only nil ones are defaulted
+		return state.trace.skip(path, field, "not nil-able")
+	}
+
+	if r.neverAllocateCollections && isCollection(field.Type) {
+		return state.trace.skip(path, field, "collections are not allocated")
+	}
+
+	isSet := fieldValue.IsValid() && !isZero(fieldValue) && !r.isDefaultableEmptyCollection(fieldValue)
+	if isSet && !r.overwriteExisting {
+		// we do not overwrite non-zero values, unless asked to
+		// IsZero doesn't panic for any kind, so the chan and func fields are skipped here if they are non-nil,
+		// and reported as not supported below only if they have a default value.
+		return state.trace.skip(path, field, "non-zero value")
+	}
+
+	defaultStr, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+	if err != nil {
+		return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+	}
+	if !found {
+		return state.trace.skip(path, field, "no default value")
+	}
+
+	// we don't allow pointers to pointers
+	if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Ptr {
+		return NewError(nil, ErrNotSupported, path, field, "pointer to pointer is not allowed")
+	}
+
+	kind := field.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = field.Type.Elem().Kind()
+	}
+	defaulters, ok := r.defaultersFor(kind, addFieldToPath(path, field))
+	if !ok {
+		if r.unknownKindHandler == nil {
+			return NewError(nil, ErrNotSupported, path, field, fmt.Sprintf("no defaulters found for kind '%s'", kind))
+		}
+		defaulters = []DefaulterWithPrecedence{{Defaulter: unknownKindDefaulter{handler: r.unknownKindHandler}}}
+	}
+
+	if !fieldValue.CanSet() {
+		if r.ignoreCannotSet {
+			return state.trace.skip(path, field, "cannot set field")
+		}
+		return NewError(nil, ErrCannotSetField, path, field, "cannot set field")
+	}
+
+	set, err := r.applyDefault(state, defaulters, defaultStr, path, field, fieldValue)
+	if err == nil && !set {
+		return state.trace.skip(path, field, "no defaulter set the value")
+	}
+	return err
+}
+
+// unknownKindDefaulter adapts an UnknownKindHandler to a Defaulter, so that the default values passed to the
+// handler are resolved and recorded like the ones passed to the defaulters.
+type unknownKindDefaulter struct {
+	handler UnknownKindHandler
+}
+
+func (u unknownKindDefaulter) Name() string {
+	return "defaultz.UnknownKindHandler"
+}
+
+func (u unknownKindDefaulter) HandledKinds() []reflect.Kind {
+	return nil
+}
+
+//nolint:lll
+func (u unknownKindDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	if err := u.handler(field, value, fieldValue); err != nil {
+		return false, false, NewError(u, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+	return false, true, nil
+}
+
+// isDefaultableEmptyCollection returns true if the value is an empty, but non-nil, slice or map and the empty
+// collections are defaulted.
+func (r *defaulterRegistry) isDefaultableEmptyCollection(value reflect.Value) bool {
+	if !r.applyToEmptyCollections {
+		return false
+	}
+	return (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0
+}
+
+// splitItems splits the default value of a slice or a map into its items with the collection separator, or by
+// whitespace if it is not set. The registry can be nil, e.g. for the defaulters not created by [WithBasicDefaulters].
+// A separator escaped with a backslash is kept in the item.
+func (r *defaulterRegistry) splitItems(value string) []string {
+	if r == nil || r.collectionSeparator == "" {
+		return strings.Fields(value)
+	}
+	return splitTrimmed(value, r.collectionSeparator)
+}
+
+// splitTrimmed splits the value by the separator, see splitEscaped, and trims the items. A trailing separator is
+// tolerated.
+func splitTrimmed(value string, separator string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	items := splitEscaped(value, separator)
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	// tolerate a trailing separator, e.g. in generated tags
+	if items[len(items)-1] == "" {
+		items = items[:len(items)-1]
+	}
+	return items
+}
+
+// splitEscaped splits the value by the separator, except for the separators escaped with a backslash, which are
+// unescaped. The other backslashes are kept as they are.
+func splitEscaped(value string, separator string) []string {
+	escaped := `\` + separator
+	if !strings.Contains(value, escaped) {
+		return strings.Split(value, separator)
+	}
+
+	var items []string
+	var item strings.Builder
+	for len(value) > 0 {
+		switch {
+		case strings.HasPrefix(value, escaped):
+			item.WriteString(separator)
+			value = value[len(escaped):]
+		case strings.HasPrefix(value, separator):
+			items = append(items, item.String())
+			item.Reset()
+			value = value[len(separator):]
+		default:
+			item.WriteByte(value[0])
+			value = value[1:]
+		}
+	}
+	return append(items, item.String())
+}
+
+// isStructCollection returns true if the type is a slice or an array of structs, or of pointers to structs, or a
+// collection of such collections, e.g. [][]Child or map[string][]Child.
+func isStructCollection(t reflect.Type) bool {
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elemType := t.Elem()
+		if elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct {
+			return true
+		}
+		return elemType.Kind() == reflect.Struct || isStructCollection(elemType)
+	case reflect.Map:
+		elemType := t.Elem()
+		if elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct {
+			return true
+		}
+		return elemType.Kind() == reflect.Struct || isStructCollection(elemType)
+	default:
+		return false
+	}
+}
+
+// isCollection returns true if the type is a slice or a map, or a pointer to one.
+func isCollection(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Map
+}
+
+// isZero returns true if the value is the zero value of its type. A time.Time is zero if it is the zero instant, like
+// time.Time.IsZero reports, even with a location, e.g. time.Time{}.Local().
+func isZero(value reflect.Value) bool {
+	if value.Type() == reflect.TypeFor[time.Time]() && value.CanInterface() {
+		return value.Interface().(time.Time).IsZero() //nolint:forcetypeassert // checked above
+	}
+	return value.IsZero()
+}
+
+// isNillable returns true if the values of the kind can be nil.
+func isNillable(kind reflect.Kind) bool {
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch kind {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDisallowed returns true if the kind of the type, or the kind of the pointed type, is disallowed.
+func (r *defaulterRegistry) isDisallowed(t reflect.Type) bool {
+	if len(r.disallowedKinds) == 0 {
+		return false
+	}
+	if r.disallowedKinds[t.Kind()] {
+		return true
+	}
+	return t.Kind() == reflect.Ptr && r.disallowedKinds[t.Elem().Kind()]
+}
+
+// hasDefaults returns true if the struct type at the path, or one of its nested structs, has a field with a default
+// value.
+// The results are cached per type only for the extractors whose results depend on the fields alone, see
+// isStaticExtractor. The other extractors, e.g. the ones reading the environment, are asked on every call.
+func (r *defaulterRegistry) hasDefaults(t reflect.Type, path string) bool {
+	static := isStaticExtractor(r.extractor)
+	if static {
+		if cached, ok := r.hasDefaultsCache.Load(t); ok {
+			return cached.(bool) //nolint:forcetypeassert // we only store bools in the cache
+		}
+	}
+
+	result := false
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fieldPath := addFieldToPath(path, field)
+		if _, found, err := extractDefaultAt(r.extractor, fieldPath, field); found || err != nil {
+			// errors are reported when the field is actually visited
+			result = true
+			break
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && r.hasDefaults(fieldType, fieldPath) {
+			result = true
+			break
+		}
+	}
+
+	if static {
+		r.hasDefaultsCache.Store(t, result)
+	}
+	return result
+}
+
+// isStaticExtractor returns true if the default values extracted by the extractor depend only on the fields, and not
+// on their paths or on the environment, so that they can be cached per type.
+func isStaticExtractor(extractor DefaultExtractor) bool {
+	switch e := extractor.(type) {
+	case *DefaultzExtractor, DefaultzExtractor, *PatternExtractor, PatternExtractor:
+		return true
+	case *ChainExtractor:
+		return isStaticExtractor(*e)
+	case ChainExtractor:
+		for _, inner := range e.Extractors {
+			if !isStaticExtractor(inner) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// callDefaulters calls the defaulters in order, until one of them denotes that the next defaulter should not be
+// called. It returns whether a value is set, and an error if nothing is set and there are errors.
+//
+//nolint:lll
+func callDefaulters(defaulters []DefaulterWithPrecedence, defaultStr string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, error) {
+	var result *multierror.Error
+	var somethingSet bool
+	for _, defaulterWithPrecedence := range defaulters {
+		defaulter := defaulterWithPrecedence.Defaulter
+
+		callNext, set, err := defaulter.HandleField(defaultStr, path, field, fieldValue)
+		// err is always nil for the existing defaulters. May not be nil for custom defaulters.
+		if err != nil {
+			result = multierror.Append(result, err)
+			// we continue to the next defaulter
+		}
+		if set {
+			somethingSet = true
+		}
+		if !callNext {
+			break
+		}
+	}
+	// if there's nothing set and there are errors, return an error
+	if !somethingSet && result != nil {
+		if result.Len() == 1 {
+			return false, fmt.Errorf("failed to apply default value : %w", result.Errors[0])
+		}
+		return false, fmt.Errorf("failed to apply default value: %w", result)
+	}
+	return somethingSet, nil
+}
+
+// applyStructDefault applies the default value in the tag of a struct field, or a pointer to struct field, using the
+// defaulters registered for the struct kind. It returns true if the field is handled and shouldn't be recursed into.
+//
+// The default values of the struct fields that no defaulter sets are ignored, unless the registry is configured to
+// return an error for them, or the field is declared with a type parameter, e.g. the `Value T` field of a Box[Inner].
+// Such a field is meant to be a leaf, whose default value is never applied otherwise.
+//
+//nolint:lll
+func (r *defaulterRegistry) applyStructDefault(state *applyState, path string, parent reflect.Type, field reflect.StructField, fieldValue reflect.Value) (bool, error) {
+	if !r.overwriteExisting && !isZero(fieldValue) {
+		// we do not overwrite non-zero values
+		return false, nil
+	}
+
+	defaultStr, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+	if err != nil {
+		return false, NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+	}
+	if !found {
+		return false, nil
+	}
+
+	if defaulters, ok := r.defaultersFor(reflect.Struct, addFieldToPath(path, field)); ok {
+		if !fieldValue.CanSet() {
+			if r.ignoreCannotSet {
+				return true, state.trace.skip(path, field, "cannot set field")
+			}
+			return false, NewError(nil, ErrCannotSetField, path, field, "cannot set field")
+		}
+
+		set, err := r.applyDefault(state, defaulters, defaultStr, path, field, fieldValue)
+		if err != nil || set {
+			return set, err
+		}
+	}
+
+	if field.Type.Kind() != reflect.Struct {
+		return false, nil
+	}
+	if r.errorOnStructDefaultTag {
+		return false, NewError(nil, ErrNotSupported, path, field, "no defaulters set the struct field with a default value")
+	}
+	if isTypeArgument(parent, field.Type) {
+		msg := "no defaulters set the struct field with a default value, declared with a type parameter"
+		return false, NewError(nil, ErrNotSupported, path, field, msg)
+	}
+	return false, nil
+}
+
+// isTypeArgument returns true if the type is one of the type arguments of the generic struct type, e.g. Inner for
+// Box[Inner]. Reflection doesn't tell whether a field is declared with a type parameter, so the fields of the types of
+// the type arguments are assumed to be.
+func isTypeArgument(structType reflect.Type, t reflect.Type) bool {
+	name := structType.Name()
+	start := strings.Index(name, "[")
+	if start < 0 || !strings.HasSuffix(name, "]") {
+		return false
+	}
+
+	// the type arguments are written with the package paths of the named types, e.g. Box[example.com/pkg.Inner]
+	arg := t.String()
+	if t.Name() != "" && t.PkgPath() != "" {
+		arg = t.PkgPath() + "." + t.Name()
+	}
+	return slices.Contains(splitTag(name[start+1:len(name)-1], ","), arg)
+}
+
+// applyDefault resolves the default value and calls the defaulters with it. It returns true if a value is set.
+//
+// If an alternative separator is set, the alternatives in the default value are tried in order and the first one
+// that is applied without errors wins. Only the invalid values move on to the next alternative, see
+// isInvalidDefaultValue, the other errors are returned right away. The errors of all alternatives are returned if none
+// of them can be applied.
+//
+//nolint:lll
+func (r *defaulterRegistry) applyDefault(state *applyState, defaulters []DefaulterWithPrecedence, defaultStr string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, error) {
+	alternatives := []string{defaultStr}
+	if r.alternativeSeparator != "" && strings.Contains(defaultStr, r.alternativeSeparator) {
+		alternatives = strings.Split(defaultStr, r.alternativeSeparator)
+		for i := range alternatives {
+			alternatives[i] = strings.TrimSpace(alternatives[i])
+		}
+	}
+
+	state.record(fieldValue)
+
+	var setBy []string
+	if state.trace != nil {
+		defaulters = traceDefaulters(defaulters, &setBy)
+	}
+	if r.metrics != nil {
+		defaulters = measureDefaulters(defaulters, r.metrics)
+	}
+
+	var result *multierror.Error
+	for _, alternative := range alternatives {
+		expanded, err := r.expandDefault(state, path, field, alternative)
+		if err != nil {
+			return false, NewError(nil, ErrInvalidDefaultValue, path, field, err.Error())
+		}
+
+		// the validator rejects the default value as a whole, the other alternatives are not tried
+		if r.validator != nil {
+			if err = r.validator(path, field, expanded); err != nil {
+				return false, NewError(nil, ErrInvalidDefaultValue, path, field, err.Error())
+			}
+		}
+
+		if state.validating && r.needsResolving(expanded) {
+			// the references and the generators are checked only when the defaults are applied
+			return false, nil
+		}
+
+		resolved, err := r.resolveDefault(state, path, field, expanded)
+		if err != nil {
+			var fieldErr *Error
+			if !errors.As(err, &fieldErr) {
+				// the error of a generator, rather than the one of a referenced field
+				fieldErr = NewError(nil, ErrInvalidDefaultValue, path, field, err.Error())
+			}
+			if !isInvalidDefaultValue(err) {
+				return false, fieldErr
+			}
+			result = multierror.Append(result, fieldErr)
+			continue
+		}
+
+		set, err := callDefaulters(defaulters, resolved, path, field, fieldValue)
+		if err == nil {
+			if set {
+				entry := TraceEntry{Outcome: TraceSet, Default: alternative, Value: resolved, Defaulters: setBy}
+				state.trace.add(path, field, entry)
+			}
+			return set, nil
+		}
+		if !isInvalidDefaultValue(err) {
+			return false, err
+		}
+		result = multierror.Append(result, err)
+	}
+
+	if result.Len() == 1 {
+		return false, result.Errors[0]
+	}
+	return false, fmt.Errorf("none of the alternatives can be applied: %w", result)
+}
+
+// expandDefault evaluates the default value as a template, if enabled, and expands the macros in it. The default value
+// is returned as is if there is nothing to expand.
+//
+//nolint:lll
+func (r *defaulterRegistry) expandDefault(state *applyState, path string, field reflect.StructField, defaultStr string) (string, error) {
+	if r.templates && strings.Contains(defaultStr, "{{") {
+		tmpl, err := template.New(path + "." + field.Name).Option("missingkey=error").Parse(defaultStr)
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		if err = tmpl.Execute(&sb, state.data); err != nil {
+			return "", err
+		}
+		defaultStr = sb.String()
+	}
+
+	return r.expandMacros(defaultStr)
+}
+
+// resolveDefault resolves the reference in the default value, or passes it to the first generator with a matching
+// prefix. The default value is returned as is if there is nothing to resolve.
+//
+//nolint:lll
+func (r *defaulterRegistry) resolveDefault(state *applyState, path string, field reflect.StructField, defaultStr string) (string, error) {
+	var err error
+	if ref, ok := strings.CutPrefix(defaultStr, ReferencePrefix); ok {
+		if defaultStr, err = r.resolveReference(state, path, field, ref); err != nil {
+			return "", err
+		}
+	} else {
+		for _, gwp := range r.generators {
+			if arg, ok := strings.CutPrefix(defaultStr, gwp.prefix); ok {
+				if defaultStr, err = gwp.generator(path, field, arg); err != nil {
+					return "", err
+				}
+				break
+			}
+		}
+	}
+	return defaultStr, nil
+}
+
+// needsResolving returns true if the default value is a reference, or is passed to a generator by resolveDefault.
+func (r *defaulterRegistry) needsResolving(defaultStr string) bool {
+	if strings.HasPrefix(defaultStr, ReferencePrefix) {
+		return true
+	}
+	for _, gwp := range r.generators {
+		if strings.HasPrefix(defaultStr, gwp.prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a human-readable description of the registry configuration: the extractor, the flags, the value
+// generators and the defaulters per kind with their precedences, in the order they are called.
+func (r *defaulterRegistry) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("defaultz.DefaulterRegistry:\n")
+
+	extractor := "<nil>"
+	if r.extractor != nil {
+		if stringer, ok := r.extractor.(fmt.Stringer); ok {
+			extractor = stringer.String()
+		} else {
+			extractor = fmt.Sprintf("%T", r.extractor)
+		}
+	}
+	fmt.Fprintf(&sb, "  extractor: %s\n", extractor)
+	fmt.Fprintf(&sb, "  ignoreCannotSet: %t\n", r.ignoreCannotSet)
+	fmt.Fprintf(&sb, "  errorOnStructDefaultTag: %t\n", r.errorOnStructDefaultTag)
+	fmt.Fprintf(&sb, "  templates: %t\n", r.templates)
+	fmt.Fprintf(&sb, "  collectAllErrors: %t\n", r.collectAllErrors)
+	fmt.Fprintf(&sb, "  applyToEmptyCollections: %t\n", r.applyToEmptyCollections)
+	fmt.Fprintf(&sb, "  defaultNilOnly: %t\n", r.defaultNilOnly)
+	fmt.Fprintf(&sb, "  neverAllocateCollections: %t\n", r.neverAllocateCollections)
+	fmt.Fprintf(&sb, "  overwriteExisting: %t\n", r.overwriteExisting)
+	fmt.Fprintf(&sb, "  collectionSeparator: %q\n", r.collectionSeparator)
+	fmt.Fprintf(&sb, "  alternativeSeparator: %q\n", r.alternativeSeparator)
+	fmt.Fprintf(&sb, "  rootLabel: %q\n", r.rootLabel)
+	fmt.Fprintf(&sb, "  finalizers: %d\n", len(r.finalizers))
+	fmt.Fprintf(&sb, "  skipPredicates: %d\n", len(r.skipPredicates))
+	fmt.Fprintf(&sb, "  unknownKindHandler: %t\n", r.unknownKindHandler != nil)
+	fmt.Fprintf(&sb, "  metrics: %t\n", r.metrics != nil)
+
+	disallowed := make([]string, 0, len(r.disallowedKinds))
+	for kind := range r.disallowedKinds {
+		disallowed = append(disallowed, kind.String())
+	}
+	sort.Strings(disallowed)
+	fmt.Fprintf(&sb, "  disallowedKinds: [%s]\n", strings.Join(disallowed, ", "))
+	fmt.Fprintf(&sb, "  maxFields: %d\n", r.maxFields)
+
+	prefixes := make([]string, 0, len(r.generators))
+	for _, gwp := range r.generators {
+		prefixes = append(prefixes, fmt.Sprintf("%q", gwp.prefix))
+	}
+	fmt.Fprintf(&sb, "  generators: [%s]\n", strings.Join(prefixes, ", "))
+	fmt.Fprintf(&sb, "  constants: %d\n", len(r.constants))
+	fmt.Fprintf(&sb, "  macros: %d\n", len(r.macros))
+	fmt.Fprintf(&sb, "  typeFuncs: %d\n", len(r.typeFuncs))
+
+	kinds := make([]reflect.Kind, 0, len(r.defaulters))
+	for kind := range r.defaulters {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i] < kinds[j]
+	})
+
+	sb.WriteString("  defaulters:\n")
+	for _, kind := range kinds {
+		names := make([]string, 0, len(r.defaulters[kind]))
+		for _, dwp := range r.defaulters[kind] {
+			names = append(names, fmt.Sprintf("%s(%d)", dwp.Defaulter.Name(), dwp.Precedence))
+		}
+		fmt.Fprintf(&sb, "    %s: %s\n", kind, strings.Join(names, ", "))
+	}
+
+	paths := make([]string, 0, len(r.pathDefaulters))
+	for path := range r.pathDefaulters {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	sb.WriteString("  pathDefaulters:\n")
+	for _, path := range paths {
+		names := make([]string, 0, len(r.pathDefaulters[path]))
+		for _, dwp := range r.pathDefaulters[path] {
+			names = append(names, fmt.Sprintf("%s(%d)", dwp.Defaulter.Name(), dwp.Precedence))
+		}
+		fmt.Fprintf(&sb, "    %s: %s\n", path, strings.Join(names, ", "))
+	}
+
+	return sb.String()
+}
+
+func addFieldToPath(path string, field reflect.StructField) string {
+	return path + "." + field.Name
+}
+
+func addIndexToPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+// addKeyToPath adds a map key to the path, e.g. `<root>.Servers[web]`.
+func addKeyToPath(path string, key reflect.Value) string {
+	return fmt.Sprintf("%s[%v]", path, key)
 }
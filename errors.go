@@ -20,12 +20,21 @@ var ErrInvalidDefaultValue = errors.New("invalid default value")
 // This is used when the default value is a slice or a map.
 var ErrInvalidDefaultValueItem = errors.New("invalid default value item")
 
-// ErrInvalidDefaultValueKey is returned when the key of a map default value is invalid.
+// ErrInvalidDefaultValueKey is returned when the key of a map default value or the index of a slice default value
+// is invalid.
 var ErrInvalidDefaultValueKey = errors.New("invalid default value key")
 
 // ErrNotSupported is returned when the operation is not supported.
 var ErrNotSupported = errors.New("not supported")
 
+// ErrIncomplete is returned when a field is left with its zero value, without a default value.
+// See [ExtendedDefaulterRegistry.ApplyDefaultsStrictComplete] for more information.
+var ErrIncomplete = errors.New("incomplete field")
+
+// ErrMaxFieldsExceeded is returned when more fields are visited than allowed.
+// See [WithMaxFields] for more information.
+var ErrMaxFieldsExceeded = errors.New("maximum number of fields exceeded")
+
 type Error struct {
 	Defaulter Defaulter
 	Err       error
@@ -64,3 +73,25 @@ func (e *Error) Error() string {
 }
 
 func (e *Error) Unwrap() error { return e.Err }
+
+// invalidValueError is an error of a value generator for an invalid default value, e.g. an unknown constant, which
+// wraps ErrInvalidDefaultValue without repeating it in the message.
+type invalidValueError struct {
+	msg string
+}
+
+func newInvalidValueError(format string, args ...any) error {
+	return invalidValueError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e invalidValueError) Error() string { return e.msg }
+
+func (e invalidValueError) Unwrap() error { return ErrInvalidDefaultValue }
+
+// isInvalidDefaultValue returns true if the error is about an invalid default value, its items or its keys, rather
+// than about resolving or applying the default value.
+func isInvalidDefaultValue(err error) bool {
+	return errors.Is(err, ErrInvalidDefaultValue) ||
+		errors.Is(err, ErrInvalidDefaultValueItem) ||
+		errors.Is(err, ErrInvalidDefaultValueKey)
+}
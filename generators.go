@@ -0,0 +1,213 @@
+package defaultz
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WithPathGenerators registers value generators for the following prefixes, which expand to OS-specific directories:
+//
+//   - `$home`: [os.UserHomeDir]
+//   - `$config_dir`: [os.UserConfigDir]
+//   - `$cache_dir`: [os.UserCacheDir]
+//   - `$temp`: [os.TempDir]
+//
+// The rest of the default value is joined to the directory, e.g. `default:"$config_dir/app"` yields
+// "/home/user/.config/app" on Linux.
+//
+// The directories are looked up when the defaults are applied. Errors from the os package functions are wrapped as
+// [ErrInvalidDefaultValue].
+func WithPathGenerators() DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.generators = append(r.generators,
+			valueGeneratorWithPrefix{prefix: "$home", generator: pathGenerator(os.UserHomeDir)},
+			valueGeneratorWithPrefix{prefix: "$config_dir", generator: pathGenerator(os.UserConfigDir)},
+			valueGeneratorWithPrefix{prefix: "$cache_dir", generator: pathGenerator(os.UserCacheDir)},
+			valueGeneratorWithPrefix{prefix: "$temp", generator: pathGenerator(func() (string, error) {
+				return os.TempDir(), nil
+			})},
+		)
+	}
+}
+
+func pathGenerator(dirFunc func() (string, error)) valueGenerator {
+	return func(_ string, _ reflect.StructField, arg string) (string, error) {
+		// the directory should be followed by a path separator, e.g. `$home/foo`, but not `$homefoo`
+		if arg != "" && !strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, string(filepath.Separator)) {
+			return "", fmt.Errorf("invalid path '%s', expected a path separator after the directory", arg)
+		}
+
+		dir, err := dirFunc()
+		if err != nil {
+			return "", err
+		}
+		if arg == "" {
+			return dir, nil
+		}
+		return filepath.Join(dir, arg), nil
+	}
+}
+
+// SecretPrefix is the prefix of default values that are resolved through the [SecretProvider] registered with
+// [WithSecretProvider], e.g. `default:"secret:db-password"`.
+const SecretPrefix = "secret:"
+
+// SecretProvider provides secrets by name, e.g. from a secret store.
+type SecretProvider interface {
+	// Get returns the secret with the name, or an error if the secret cannot be provided.
+	Get(name string) (string, error)
+}
+
+// WithSecretProvider registers a value generator for the default values starting with the SecretPrefix, which
+// resolves them through the provider. For example, `default:"secret:db-password"` yields the secret "db-password".
+//
+// Errors from the provider, e.g. for missing secrets, are wrapped as [ErrInvalidDefaultValue] with the name of the
+// secret.
+func WithSecretProvider(provider SecretProvider) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.generators = append(r.generators, valueGeneratorWithPrefix{
+			prefix: SecretPrefix,
+			generator: func(_ string, _ reflect.StructField, name string) (string, error) {
+				secret, err := provider.Get(name)
+				if err != nil {
+					return "", fmt.Errorf("cannot get secret '%s': %w", name, err)
+				}
+				return secret, nil
+			},
+		})
+	}
+}
+
+// FlagPrefix is the prefix of default values that are resolved from the flags of the [flag.FlagSet] registered with
+// [WithFlagSet], e.g. `default:"flag:port"`.
+const FlagPrefix = "flag:"
+
+// WithFlagSet registers a value generator for the default values starting with the FlagPrefix, which resolves them to
+// the current values of the flags of the flag set. For example, `default:"flag:port"` yields the value of the "port"
+// flag, which is the default value of the flag if it is not set on the command line.
+//
+// The flags are looked up when the defaults are applied, so the flag set should be parsed before. Unknown flags
+// result in an [ErrInvalidDefaultValue] error.
+func WithFlagSet(fs *flag.FlagSet) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.generators = append(r.generators, valueGeneratorWithPrefix{
+			prefix: FlagPrefix,
+			generator: func(_ string, _ reflect.StructField, name string) (string, error) {
+				f := fs.Lookup(name)
+				if f == nil {
+					return "", fmt.Errorf("unknown flag '%s'", name)
+				}
+				return f.Value.String(), nil
+			},
+		})
+	}
+}
+
+// EnvFilePrefix is the prefix of default values that are resolved from the entries registered with [WithEnvFile],
+// e.g. `default:"envfile:DATABASE_URL"`.
+const EnvFilePrefix = "envfile:"
+
+// WithEnvFile registers a value generator for the default values starting with the EnvFilePrefix, which resolves them
+// to the entries of a parsed dotenv file. For example, `default:"envfile:DATABASE_URL"` yields the DATABASE_URL entry.
+//
+// Missing keys result in an [ErrInvalidDefaultValue] error. To fall back to another value instead, use the
+// alternatives of [WithAlternativeSeparator], e.g. `default:"envfile:DATABASE_URL || postgres://localhost"`.
+func WithEnvFile(entries map[string]string) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.generators = append(r.generators, valueGeneratorWithPrefix{
+			prefix: EnvFilePrefix,
+			generator: func(_ string, _ reflect.StructField, key string) (string, error) {
+				value, ok := entries[key]
+				if !ok {
+					return "", newInvalidValueError("env file entry '%s' not found", key)
+				}
+				return value, nil
+			},
+		})
+	}
+}
+
+// RandPrefix is the prefix of default values that are generated pseudo-randomly by the generator registered with
+// [WithRandSeed], e.g. `default:"rand:int"`.
+const RandPrefix = "rand:"
+
+const randLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// maxRandStringLength is the maximum length of the `rand:string:<length>` values, so that a typo in a tag can't
+// allocate an enormous string.
+const maxRandStringLength = 4096
+
+// WithRandSeed registers a value generator for the default values starting with the RandPrefix, which generates
+// pseudo-random placeholder values, e.g. for test fixtures:
+//
+//   - `rand:int`: a non-negative integer that fits the field, e.g. below 128 for an int8 field
+//   - `rand:float`: a floating-point number in [0.0, 1.0)
+//   - `rand:string:<length>`: an alphanumeric string of the length, up to 4096, e.g. `rand:string:8`
+//
+// The values are generated from a source with the seed, so that the same seed produces the same values for the same
+// sequence of fields. Use a varying seed, such as time.Now().UnixNano(), for different values on every run.
+//
+// The values are not suitable for security-sensitive purposes. Unknown forms result in an [ErrInvalidDefaultValue]
+// error.
+func WithRandSeed(seed int64) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		g := &randGenerator{rng: rand.New(rand.NewSource(seed))} //nolint:gosec	// placeholder values, not secrets
+		r.generators = append(r.generators, valueGeneratorWithPrefix{prefix: RandPrefix, generator: g.generate})
+	}
+}
+
+type randGenerator struct {
+	// rand.Rand is not safe for concurrent use
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (g *randGenerator) generate(_ string, field reflect.StructField, arg string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case arg == "int":
+		return strconv.FormatInt(g.randInt(field.Type), 10), nil
+	case arg == "float":
+		return strconv.FormatFloat(g.rng.Float64(), 'g', -1, 64), nil
+	case strings.HasPrefix(arg, "string:"):
+		length, err := strconv.Atoi(strings.TrimPrefix(arg, "string:"))
+		if err != nil || length < 0 || length > maxRandStringLength {
+			return "", fmt.Errorf("invalid random string length in '%s', must be between 0 and %d", arg,
+				maxRandStringLength)
+		}
+		b := make([]byte, length)
+		for i := range b {
+			b[i] = randLetters[g.rng.Intn(len(randLetters))]
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("unknown random value '%s', expected int, float or string:<length>", arg)
+	}
+}
+
+// randInt returns a non-negative integer that fits the integer type t, or any non-negative int64 for other types.
+func (g *randGenerator) randInt(t reflect.Type) int64 {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		// int and uint are 32 bits on some platforms
+		if t.Bits() < 64 { //nolint:mnd	// bits of int64
+			return g.rng.Int63n(1 << (t.Bits() - 1))
+		}
+	}
+	return g.rng.Int63()
+}
@@ -0,0 +1,329 @@
+package defaultz_test
+
+import (
+	"errors"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aliok/go-defaultz"
+)
+
+func TestApplyDefaultsPathGenerators(t *testing.T) {
+	newRegistry := func() defaultz.DefaulterRegistry {
+		return defaultz.NewDefaulterRegistry(
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			),
+			defaultz.WithPathGenerators(),
+		)
+	}
+
+	t.Run("Expansion", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("XDG_CACHE_HOME", "")
+		t.Setenv("TMPDIR", t.TempDir())
+
+		obj := &struct {
+			Home      string  `default:"$home"`
+			Config    string  `default:"$config_dir/app"`
+			Cache     *string `default:"$cache_dir/app/cache"`
+			Temp      string  `default:"$temp/app"`
+			NotAPath  string  `default:"home"`
+			HomeSlash string  `default:"$home/"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+
+		configDir, err := os.UserConfigDir()
+		require.NoError(t, err)
+		cacheDir, err := os.UserCacheDir()
+		require.NoError(t, err)
+
+		assert.Equal(t, home, obj.Home)
+		assert.Equal(t, filepath.Join(configDir, "app"), obj.Config)
+		assert.Equal(t, filepath.Join(cacheDir, "app", "cache"), *obj.Cache)
+		assert.Equal(t, filepath.Join(os.TempDir(), "app"), obj.Temp)
+		assert.Equal(t, "home", obj.NotAPath)
+		assert.Equal(t, home, obj.HomeSlash)
+	})
+
+	t.Run("Directory not available", func(t *testing.T) {
+		t.Setenv("HOME", "")
+
+		obj := &struct {
+			Home string `default:"$home/app"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+		assert.Contains(t, err.Error(), "path:'<root>.Home`")
+	})
+
+	t.Run("No separator after the directory", func(t *testing.T) {
+		obj := &struct {
+			Home string `default:"$homeapp"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+		assert.Contains(t, err.Error(), "invalid path 'app', expected a path separator after the directory")
+	})
+}
+
+type fakeSecretProvider map[string]string
+
+func (f fakeSecretProvider) Get(name string) (string, error) {
+	secret, ok := f[name]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+func TestApplyDefaultsSecretProvider(t *testing.T) {
+	newRegistry := func() defaultz.DefaulterRegistry {
+		return defaultz.NewDefaulterRegistry(
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			),
+			defaultz.WithSecretProvider(fakeSecretProvider{
+				"db-password": "s3cret",
+				"db-port":     "5432",
+			}),
+		)
+	}
+
+	t.Run("Resolved", func(t *testing.T) {
+		obj := &struct {
+			Password string `default:"secret:db-password"`
+			Port     int    `default:"secret:db-port"`
+			Plain    string `default:"plain"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", obj.Password)
+		assert.Equal(t, 5432, obj.Port)
+		assert.Equal(t, "plain", obj.Plain)
+	})
+
+	t.Run("Missing secret", func(t *testing.T) {
+		obj := &struct {
+			Password string `default:"secret:missing"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+		assert.EqualError(t, err, "invalid default value - cannot get secret 'missing': secret not found, "+
+			"path:'<root>.Password`, "+
+			"field:'Password string `default:\"secret:missing\"`'")
+		assert.Empty(t, obj.Password)
+	})
+}
+
+func TestApplyDefaultsFlagSet(t *testing.T) {
+	newRegistry := func() defaultz.DefaulterRegistry {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.String("host", "localhost", "")
+		fs.Int("port", 8080, "")
+		fs.Bool("verbose", false, "")
+		fs.Duration("timeout", time.Second, "")
+		require.NoError(t, fs.Parse([]string{"-port", "9090", "-verbose"}))
+
+		return defaultz.NewDefaulterRegistry(
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			),
+			defaultz.WithFlagSet(fs),
+		)
+	}
+
+	t.Run("Resolved", func(t *testing.T) {
+		obj := &struct {
+			Host    string        `default:"flag:host"`
+			Port    int           `default:"flag:port"`
+			Verbose *bool         `default:"flag:verbose"`
+			Timeout time.Duration `default:"flag:timeout"`
+			Plain   string        `default:"plain"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", obj.Host)
+		assert.Equal(t, 9090, obj.Port)
+		require.NotNil(t, obj.Verbose)
+		assert.True(t, *obj.Verbose)
+		assert.Equal(t, time.Second, obj.Timeout)
+		assert.Equal(t, "plain", obj.Plain)
+	})
+
+	t.Run("Unknown flag", func(t *testing.T) {
+		obj := &struct {
+			Host string `default:"flag:missing"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+		assert.EqualError(t, err, "invalid default value - unknown flag 'missing', "+
+			"path:'<root>.Host`, "+
+			"field:'Host string `default:\"flag:missing\"`'")
+		assert.Empty(t, obj.Host)
+	})
+}
+
+func TestApplyDefaultsEnvFile(t *testing.T) {
+	newRegistry := func(options ...defaultz.DefaulterRegistryOption) defaultz.DefaulterRegistry {
+		return defaultz.NewDefaulterRegistry(append([]defaultz.DefaulterRegistryOption{
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			),
+			defaultz.WithEnvFile(map[string]string{
+				"DATABASE_URL": "postgres://db:5432/app",
+				"PORT":         "9090",
+				"EMPTY":        "",
+			}),
+		}, options...)...)
+	}
+
+	t.Run("Resolved", func(t *testing.T) {
+		obj := &struct {
+			DatabaseURL string `default:"envfile:DATABASE_URL"`
+			Port        int    `default:"envfile:PORT"`
+			Empty       string `default:"envfile:EMPTY"`
+			Plain       string `default:"plain"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://db:5432/app", obj.DatabaseURL)
+		assert.Equal(t, 9090, obj.Port)
+		assert.Empty(t, obj.Empty)
+		assert.Equal(t, "plain", obj.Plain)
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		obj := &struct {
+			Host string `default:"envfile:HOST"`
+		}{}
+
+		err := newRegistry().ApplyDefaults(obj)
+		require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+		assert.EqualError(t, err, "invalid default value - env file entry 'HOST' not found, "+
+			"path:'<root>.Host`, "+
+			"field:'Host string `default:\"envfile:HOST\"`'")
+		assert.Empty(t, obj.Host)
+	})
+
+	t.Run("Missing key with a fallback", func(t *testing.T) {
+		obj := &struct {
+			Host string `default:"envfile:HOST || localhost"`
+			Port int    `default:"envfile:PORT || 8080"`
+		}{}
+
+		err := newRegistry(defaultz.WithAlternativeSeparator("||")).ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", obj.Host)
+		assert.Equal(t, 9090, obj.Port)
+	})
+}
+
+func TestApplyDefaultsRandSeed(t *testing.T) {
+	type fixture struct {
+		ID     int     `default:"rand:int"`
+		Small  int8    `default:"rand:int"`
+		Port   *uint16 `default:"rand:int"`
+		Ratio  float64 `default:"rand:float"`
+		Name   string  `default:"rand:string:8"`
+		Token  string  `default:"rand:string:16"`
+		Number string  `default:"rand:int"`
+	}
+
+	newRegistry := func(seed int64) defaultz.DefaulterRegistry {
+		return defaultz.NewDefaulterRegistry(
+			defaultz.WithBasicDefaulters(),
+			defaultz.WithDefaultExtractor(
+				defaultz.NewDefaultzExtractor("default", "", ","),
+			),
+			defaultz.WithRandSeed(seed),
+		)
+	}
+
+	t.Run("Generated", func(t *testing.T) {
+		obj := &fixture{}
+
+		err := newRegistry(42).ApplyDefaults(obj)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, obj.ID, 0)
+		assert.GreaterOrEqual(t, obj.Small, int8(0))
+		require.NotNil(t, obj.Port)
+		assert.LessOrEqual(t, *obj.Port, uint16(math.MaxInt16))
+		assert.GreaterOrEqual(t, obj.Ratio, 0.0)
+		assert.Less(t, obj.Ratio, 1.0)
+		assert.Regexp(t, "^[a-zA-Z0-9]{8}$", obj.Name)
+		assert.Regexp(t, "^[a-zA-Z0-9]{16}$", obj.Token)
+		assert.Regexp(t, "^[0-9]+$", obj.Number)
+	})
+
+	t.Run("Deterministic under a fixed seed", func(t *testing.T) {
+		first := &fixture{}
+		second := &fixture{}
+
+		require.NoError(t, newRegistry(42).ApplyDefaults(first))
+		require.NoError(t, newRegistry(42).ApplyDefaults(second))
+		assert.Equal(t, first, second)
+
+		other := &fixture{}
+		require.NoError(t, newRegistry(7).ApplyDefaults(other))
+		assert.NotEqual(t, first.Token, other.Token)
+	})
+
+	t.Run("Maximum string length", func(t *testing.T) {
+		obj := &struct {
+			F string `default:"rand:string:4096"`
+		}{}
+
+		require.NoError(t, newRegistry(42).ApplyDefaults(obj))
+		assert.Len(t, obj.F, 4096)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		objs := []any{
+			&struct {
+				F string `default:"rand:bool"`
+			}{},
+			&struct {
+				F string `default:"rand:string"`
+			}{},
+			&struct {
+				F string `default:"rand:string:abc"`
+			}{},
+			&struct {
+				F string `default:"rand:string:-1"`
+			}{},
+			&struct {
+				F string `default:"rand:string:1000000000"`
+			}{},
+		}
+
+		for _, obj := range objs {
+			err := newRegistry(42).ApplyDefaults(obj)
+			require.ErrorIs(t, err, defaultz.ErrInvalidDefaultValue)
+			assert.Equal(t, reflect.New(reflect.TypeOf(obj).Elem()).Interface(), obj)
+		}
+	})
+}
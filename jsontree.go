@@ -0,0 +1,205 @@
+package defaultz
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ApplyDefaultsFromJSONTree applies default values to the zero fields of the struct from a tree of default values,
+// e.g. a JSON document decoded into a map[string]any, and then from the tags. The tree wins: a field with a value in
+// the tree is not set from its tag.
+//
+// The fields are looked up in the tree by their JSON paths, built from the names in the `json` tags like
+// [encoding/json] does, e.g. the field `Port` of the field `Server` is looked up at `/server/port` with the tags
+// `json:"server"` and `json:"port"`. The names are matched case-insensitively if there's no exact match. Fields with
+// `json:"-"` are skipped and the fields of embedded structs without a name are looked up in the parent object. The nil
+// pointers to structs are allocated only if the tree has a value for them.
+//
+// The values in the tree are passed to the defaulters as default values, so that the same formats are supported, e.g.
+// `"30s"` for a time.Duration field. Arrays and objects are passed as JSON, for the slice and map fields. The nested
+// objects are applied to the struct fields, including the pointers to structs, field by field.
+func (r *defaulterRegistry) ApplyDefaultsFromJSONTree(obj interface{}, tree map[string]any) error {
+	val, err := checkRoot(obj)
+	if err != nil {
+		return err
+	}
+
+	// the tree values are set first, so the tree wins over the tags
+	if err := r.applyJSONTree(val.Elem(), tree, r.rootPath(val.Elem().Type())); err != nil {
+		return err
+	}
+	return r.ApplyDefaults(obj)
+}
+
+// applyJSONTree sets the zero fields of the struct value to the values of the same fields in the tree.
+func (r *defaulterRegistry) applyJSONTree(value reflect.Value, tree map[string]any, path string) error {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		if name == "" && field.Anonymous && isStructOrPointerToStruct(field.Type) {
+			// the fields of embedded structs are in the parent object
+			isNilPtr := fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil()
+			if isNilPtr && !hasJSONTreeValues(field.Type.Elem(), tree) {
+				// nothing to set in the embedded struct, it is not allocated
+				continue
+			}
+			if err := r.applyJSONSubtree(fieldValue, tree, addFieldToPath(path, field)); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		treeValue, found := lookupJSONName(tree, name)
+		if !found || treeValue == nil {
+			continue
+		}
+
+		// Handle nested objects field by field
+		if subtree, ok := treeValue.(map[string]any); ok && isStructOrPointerToStruct(field.Type) {
+			if err := r.applyJSONSubtree(fieldValue, subtree, addFieldToPath(path, field)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isZero(fieldValue) {
+			// we do not overwrite non-zero values
+			continue
+		}
+
+		if err := r.applyJSONValue(treeValue, path, field, fieldValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyJSONSubtree applies the tree to a struct, or a pointer to struct, field. Nil pointers are allocated.
+func (r *defaulterRegistry) applyJSONSubtree(fieldValue reflect.Value, tree map[string]any, path string) error {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	return r.applyJSONTree(fieldValue, tree, path)
+}
+
+// applyJSONValue calls the defaulters of the field with the value in the tree.
+//
+//nolint:lll
+func (r *defaulterRegistry) applyJSONValue(treeValue any, path string, field reflect.StructField, fieldValue reflect.Value) error {
+	defaultStr, err := jsonValueString(treeValue)
+	if err != nil {
+		return NewError(nil, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// we don't allow pointers to pointers
+	if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Ptr {
+		return NewError(nil, ErrNotSupported, path, field, "pointer to pointer is not allowed")
+	}
+
+	kind := field.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = field.Type.Elem().Kind()
+	}
+	defaulters, ok := r.defaultersFor(kind, addFieldToPath(path, field))
+	if !ok {
+		return NewError(nil, ErrNotSupported, path, field, fmt.Sprintf("no defaulters found for kind '%s'", kind))
+	}
+
+	_, err = callDefaulters(defaulters, defaultStr, path, field, fieldValue)
+	return err
+}
+
+// hasJSONTreeValues returns true if the tree has a value for a field of the struct type, including the fields of the
+// embedded structs.
+func hasJSONTreeValues(t reflect.Type, tree map[string]any) bool {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if name == "" && field.Anonymous && isStructOrPointerToStruct(field.Type) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if hasJSONTreeValues(embedded, tree) {
+				return true
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if treeValue, found := lookupJSONName(tree, name); found && treeValue != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName returns the name of the field in the `json` tag, empty if there's no name in the tag.
+// It returns false if the field is skipped with `json:"-"`.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+// lookupJSONName returns the value with the name in the tree, matching the names case-insensitively if there's no
+// exact match, like [encoding/json] does.
+func lookupJSONName(tree map[string]any, name string) (any, bool) {
+	if v, ok := tree[name]; ok {
+		return v, true
+	}
+	for k, v := range tree {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// jsonValueString returns the value in the tree as a default value. Arrays and objects are encoded as JSON.
+func jsonValueString(treeValue any) (string, error) {
+	switch v := treeValue.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any, map[string]any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("cannot encode the value as JSON: %w", err)
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// isStructOrPointerToStruct returns true if the type is a struct or a pointer to a struct.
+func isStructOrPointerToStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || (t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct)
+}
@@ -0,0 +1,75 @@
+package defaultz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MacroPrefix is the prefix of the macros registered with [ExtendedDefaulterRegistry.RegisterMacro] in the default
+// values, e.g. `default:"@port"` or `default:"localhost:@port"`.
+const MacroPrefix = "@"
+
+// RegisterMacro registers a macro, which is expanded to the value wherever it is used in the default values with the
+// [MacroPrefix], e.g. after registering `RegisterMacro("port", "8080")`, `default:"@port"` yields "8080" and
+// `default:"localhost:@port"` yields "localhost:8080". Registering a name again overwrites the previous value.
+//
+// The macros are expanded before the generators are called, so `default:"const:@name"` works as well.
+// A macro name starts with a letter or an underscore, followed by letters, digits or underscores. The names are
+// recognized at the start of the default value or after a character that cannot be in a name, so that the e-mail
+// addresses such as `user@example.com` and the Unix timestamps such as `@1700000000` are not expanded.
+// Unknown macros result in an error.
+func (r *defaulterRegistry) RegisterMacro(name string, value string) ExtendedDefaulterRegistry {
+	if r.macros == nil {
+		r.macros = make(map[string]string)
+	}
+	r.macros[name] = value
+	return r
+}
+
+// expandMacros expands the macros in the default value.
+func (r *defaulterRegistry) expandMacros(value string) (string, error) {
+	if len(r.macros) == 0 || !strings.Contains(value, MacroPrefix) {
+		return value, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); {
+		rest := value[i:]
+		isStart := i == 0 || !isMacroNameByte(value[i-1]) && value[i-1] != MacroPrefix[0]
+		if !isStart || !strings.HasPrefix(rest, MacroPrefix) {
+			sb.WriteByte(value[i])
+			i++
+			continue
+		}
+
+		name := macroName(rest[len(MacroPrefix):])
+		if name == "" {
+			sb.WriteByte(value[i])
+			i++
+			continue
+		}
+		expanded, ok := r.macros[name]
+		if !ok {
+			return "", fmt.Errorf("unknown macro '%s%s'", MacroPrefix, name)
+		}
+		sb.WriteString(expanded)
+		i += len(MacroPrefix) + len(name)
+	}
+	return sb.String(), nil
+}
+
+// macroName returns the macro name at the start of s, or an empty string if s doesn't start with a name.
+func macroName(s string) string {
+	end := 0
+	for end < len(s) && isMacroNameByte(s[end]) {
+		end++
+	}
+	if end == 0 || (s[0] >= '0' && s[0] <= '9') {
+		return ""
+	}
+	return s[:end]
+}
+
+func isMacroNameByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,51 @@
+package defaultz
+
+import (
+	"reflect"
+	"time"
+)
+
+// MetricsSink receives the metrics of the defaulters, e.g. to find out which defaulters cost the most.
+// See [WithMetrics].
+type MetricsSink interface {
+	// ObserveDefaulter is called after each call of a defaulter for a field, with the name of the defaulter and the
+	// time the call took. The number of calls is the number of fields the defaulter is called for.
+	ObserveDefaulter(name string, duration time.Duration)
+}
+
+// WithMetrics sets the sink that the registry reports the calls of the defaulters to. No metrics are reported if
+// not set.
+//
+// The sink is called synchronously, in the goroutine applying the defaults, so it needs to be safe to call
+// concurrently if the registry is used concurrently, and it should be fast.
+func WithMetrics(sink MetricsSink) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.metrics = sink
+	}
+}
+
+// measuringDefaulter reports the calls of a defaulter to a MetricsSink.
+type measuringDefaulter struct {
+	Defaulter
+	sink MetricsSink
+}
+
+//nolint:lll
+func (m measuringDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	start := time.Now()
+	callNext, set, err := m.Defaulter.HandleField(value, path, field, fieldValue)
+	m.sink.ObserveDefaulter(m.Name(), time.Since(start))
+	return callNext, set, err
+}
+
+// measureDefaulters wraps the defaulters to report their calls to the sink.
+func measureDefaulters(defaulters []DefaulterWithPrecedence, sink MetricsSink) []DefaulterWithPrecedence {
+	measured := make([]DefaulterWithPrecedence, len(defaulters))
+	for i, d := range defaulters {
+		measured[i] = DefaulterWithPrecedence{
+			Defaulter:  measuringDefaulter{Defaulter: d.Defaulter, sink: sink},
+			Precedence: d.Precedence,
+		}
+	}
+	return measured
+}
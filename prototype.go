@@ -0,0 +1,96 @@
+package defaultz
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ApplyDefaultsWithPrototype applies default values to the zero fields of the struct from the tags, falling back to
+// the values of the prototype for the fields without a default value. The tags win: a field with a default value is
+// never set from the prototype, even if the default value is the zero value.
+//
+// The prototype is a struct, or a pointer to a struct, of the same type as the object. The nested structs are handled
+// the same way, field by field, and the values copied from the prototype are deep copies, so that the object and the
+// prototype don't share any memory.
+//
+// The opaque structs, such as time.Time or netip.Addr, are copied whole instead: the structs without exported fields,
+// and the structs with a default value when there are defaulters for the struct kind, which handle them as a whole.
+func (r *defaulterRegistry) ApplyDefaultsWithPrototype(obj interface{}, prototype interface{}) error {
+	// the types with cycles are rejected, so that the prototype values can't be cyclic either
+	val, err := checkRoot(obj)
+	if err != nil {
+		return err
+	}
+
+	proto := reflect.ValueOf(prototype)
+	if proto.Kind() == reflect.Ptr && !proto.IsNil() {
+		proto = proto.Elem()
+	}
+	if !proto.IsValid() || proto.Type() != val.Elem().Type() {
+		return errors.New("prototype must be a struct, or a pointer to a struct, of the same type as the object")
+	}
+
+	if r.extractor == nil {
+		return errors.New("default extractor is not set")
+	}
+
+	// the prototype values are set first, to the fields without default values, so the tags win
+	if err := r.applyPrototype(val.Elem(), proto, r.rootPath(val.Elem().Type())); err != nil {
+		return err
+	}
+	return r.ApplyDefaults(obj)
+}
+
+// applyPrototype sets the zero fields of the struct value without default values to the deep copies of the values of
+// the same fields of the prototype.
+func (r *defaulterRegistry) applyPrototype(value reflect.Value, proto reflect.Value, path string) error {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		fieldValue := value.Field(i)
+		protoValue := proto.Field(i)
+		if !fieldValue.CanSet() || isZero(protoValue) {
+			continue
+		}
+
+		_, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+		if err != nil {
+			return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+		}
+
+		// Handle nested structs (including pointers to structs) field by field, unless they are opaque
+		if isStructOrPointerToStruct(field.Type) && !r.isOpaqueStruct(field, found) {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(field.Type.Elem()))
+				}
+				fieldValue, protoValue = fieldValue.Elem(), protoValue.Elem()
+			}
+			if err := r.applyPrototype(fieldValue, protoValue, addFieldToPath(path, field)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isZero(fieldValue) {
+			// we do not overwrite non-zero values
+			continue
+		}
+		if found {
+			// the default value in the tag wins
+			continue
+		}
+
+		fieldValue.Set(deepCopyValue(protoValue, make(map[copiedPointer]reflect.Value)))
+	}
+	return nil
+}
+
+// isOpaqueStruct returns true if the struct, or pointer to struct, field is handled as a whole rather than field by
+// field: the struct has no exported fields, or it has a default value for the defaulters of the struct kind.
+func (r *defaulterRegistry) isOpaqueStruct(field reflect.StructField, hasDefault bool) bool {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return !hasExportedFields(t) || (hasDefault && len(r.defaulters[reflect.Struct]) > 0)
+}
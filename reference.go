@@ -0,0 +1,110 @@
+package defaultz
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ReferencePrefix is the prefix of default values that reference another field of the root struct by its dotted path,
+// e.g. `default:"$ref:Server.Host"`. The value of the referenced field is used as the default value.
+//
+// The referenced field is defaulted first if it is zero, regardless of the order of the fields, so the references can
+// be chained. Cycles of references are reported with an error.
+const ReferencePrefix = "$ref:"
+
+// resolveReference returns the value of the field at the dotted path, relative to the root struct, as a default value.
+//
+//nolint:lll
+func (r *defaulterRegistry) resolveReference(state *applyState, path string, field reflect.StructField, ref string) (string, error) {
+	self := addFieldToPath(path, field)
+	if slices.Contains(state.resolving, self) {
+		cycle := slices.Concat(state.resolving[slices.Index(state.resolving, self):], []string{self})
+		return "", fmt.Errorf("reference cycle: %s", strings.Join(cycle, " -> "))
+	}
+	state.resolving = append(state.resolving, self)
+	defer func() { state.resolving = state.resolving[:len(state.resolving)-1] }()
+
+	if !state.root.IsValid() {
+		return "", fmt.Errorf("cannot resolve reference '%s' without a root struct", ref)
+	}
+
+	value := state.root
+	valuePath := state.rootPath
+	for i, name := range strings.Split(ref, ".") {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if !value.CanSet() {
+					return "", fmt.Errorf("cannot resolve reference '%s', a pointer on the path is nil", ref)
+				}
+				state.record(value)
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return "", fmt.Errorf("cannot resolve reference '%s', '%s' is not a struct", ref, valuePath)
+		}
+
+		refField, ok := value.Type().FieldByName(name)
+		if !ok || !refField.IsExported() || len(refField.Index) != 1 {
+			return "", fmt.Errorf("cannot resolve reference '%s', no field '%s' in '%s'", ref, name, valuePath)
+		}
+		refValue := value.Field(refField.Index[0])
+
+		if i == strings.Count(ref, ".") && isZero(refValue) {
+			// the referenced field is defaulted first
+			walk := func() error {
+				return walkValue(applyVisitor{registry: r, state: state}, refValue, addFieldToPath(valuePath, refField))
+			}
+			if err := r.applyField(state, valuePath, value.Type(), refField, refValue, walk); err != nil {
+				return "", err
+			}
+		}
+
+		value = refValue
+		valuePath = addFieldToPath(valuePath, refField)
+	}
+
+	return referenceString(value)
+}
+
+// referenceString formats the value of a referenced field as a default value.
+// The slices and arrays are formatted as space-separated items, like the default values of the slice fields.
+func referenceString(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("cannot marshal the referenced value: %w", err)
+		}
+		return string(text), nil
+	}
+
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, value.Len())
+		for i := range value.Len() {
+			item, err := referenceString(value.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return strings.Join(items, " "), nil
+	case reflect.Map, reflect.Struct, reflect.Interface, reflect.Chan, reflect.Func:
+		return "", errors.New("only scalars, slices and arrays can be referenced")
+	default:
+		return fmt.Sprint(value.Interface()), nil
+	}
+}
@@ -0,0 +1,48 @@
+package defaultz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report is the list of the fields that are set by a single call, returned by
+// [ExtendedDefaulterRegistry.ApplyDefaultsWithReport]. The entries are in the order the fields are visited.
+type Report struct {
+	Entries []ReportEntry `json:"entries"`
+}
+
+// ReportEntry is a field that is set with its default value.
+type ReportEntry struct {
+	// Path is the path of the field, e.g. `<root>.Server.Port`.
+	Path string `json:"path"`
+
+	// Value is the default value applied to the field, after resolving the templates, the constants, the macros and
+	// the generators.
+	Value string `json:"value"`
+}
+
+// Len returns the number of the fields that are set.
+func (r Report) Len() int {
+	return len(r.Entries)
+}
+
+// String returns the report as a human-readable summary, followed by one field per line.
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "applied %d defaults\n", r.Len())
+	for _, entry := range r.Entries {
+		fmt.Fprintf(&sb, "%s = %q\n", entry.Path, entry.Value)
+	}
+	return sb.String()
+}
+
+// newReport creates a report of the fields that are set in the trace.
+func newReport(trace Trace) Report {
+	report := Report{Entries: []ReportEntry{}}
+	for _, entry := range trace.Entries {
+		if entry.Outcome == TraceSet {
+			report.Entries = append(report.Entries, ReportEntry{Path: entry.Path, Value: entry.Value})
+		}
+	}
+	return report
+}
@@ -0,0 +1,124 @@
+package defaultz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//nolint:gochecknoglobals	// computed once, the package path of the Set type
+var setPkgPath = reflect.TypeFor[Set[int]]().PkgPath()
+
+// Set is a value with a presence flag, which distinguishes a value that is explicitly set to the zero value from an
+// unset one. The Value of a Set field is defaulted only if IsSet is false, even if the Value is not zero:
+//
+//	type Config struct {
+//		Retries defaultz.Set[int] `default:"3"`
+//	}
+//
+//	Config{}                                  // Retries.Value is defaulted to 3
+//	Config{Retries: defaultz.NewSet(0)}       // Retries.Value is kept as 0
+//
+// The default value is parsed by the defaulters registered for the kind of T, like the default value of a field of
+// type T. Defaulting the Value doesn't change IsSet, as the value is not set explicitly.
+//
+// A nil pointer to a Set is allocated if its Value is defaulted. With [WithOverwriteExisting], the Value is defaulted
+// even if IsSet is true, and with [WithDefaultNilOnly], only the nil pointers to Sets are defaulted.
+type Set[T any] struct {
+	// Value is the value, which is defaulted if IsSet is false.
+	Value T
+
+	// IsSet is true if the Value is set explicitly.
+	IsSet bool
+}
+
+// NewSet creates a Set with the value, which is explicitly set.
+func NewSet[T any](value T) Set[T] {
+	return Set[T]{Value: value, IsSet: true}
+}
+
+// Get returns the value and whether it is set explicitly.
+func (s Set[T]) Get() (T, bool) {
+	return s.Value, s.IsSet
+}
+
+// isSetType returns true if the type is an instance of the generic Set type, regardless of its type parameter. The
+// structs embedding a Set are not Set types.
+func isSetType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == setPkgPath && strings.HasPrefix(t.Name(), "Set[")
+}
+
+// isSetPtrType returns true if the type is a pointer to a Set type.
+func isSetPtrType(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && isSetType(t.Elem())
+}
+
+// applySet applies the default value of a Set field, or of a pointer to a Set, to its Value, unless its IsSet is
+// true. A nil pointer is allocated only if a defaulter sets the Value.
+//
+//nolint:lll
+func (r *defaulterRegistry) applySet(state *applyState, path string, field reflect.StructField, fieldValue reflect.Value) error {
+	setValue := fieldValue
+	if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+		setValue = fieldValue.Elem()
+	}
+	allocate := setValue.Kind() == reflect.Ptr
+	if !allocate {
+		if r.defaultNilOnly {
+			// zero values are explicit values, only nil pointers to Sets are defaulted
+			return state.trace.skip(path, field, "not nil-able")
+		}
+		if setValue.FieldByName("IsSet").Bool() && !r.overwriteExisting {
+			return state.trace.skip(path, field, "explicitly set")
+		}
+	}
+
+	defaultStr, found, err := extractDefaultAt(r.extractor, addFieldToPath(path, field), field)
+	if err != nil {
+		return NewError(nil, ErrCannotExtractDefault, path, field, err.Error())
+	}
+	if !found {
+		return state.trace.skip(path, field, "no default value")
+	}
+
+	if !setValue.CanSet() {
+		if r.ignoreCannotSet {
+			return state.trace.skip(path, field, "cannot set field")
+		}
+		return NewError(nil, ErrCannotSetField, path, field, "cannot set field")
+	}
+
+	// the Value is defaulted as if it was the field, with the tags of the field
+	setType := field.Type
+	if allocate {
+		setType = setType.Elem()
+	}
+	valueType, _ := setType.FieldByName("Value")
+	kind := valueType.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = valueType.Type.Elem().Kind()
+	}
+	defaulters, ok := r.defaultersFor(kind, addFieldToPath(path, field))
+	if !ok {
+		return NewError(nil, ErrNotSupported, path, field, fmt.Sprintf("no defaulters found for kind '%s'", kind))
+	}
+
+	journaled := len(state.journal)
+	if allocate {
+		state.record(fieldValue)
+		fieldValue.Set(reflect.New(setType))
+		setValue = fieldValue.Elem()
+	}
+
+	valueField := reflect.StructField{Name: field.Name, Type: valueType.Type, Tag: field.Tag}
+	set, err := r.applyDefault(state, defaulters, defaultStr, path, valueField, setValue.FieldByName("Value"))
+	if allocate && (err != nil || !set) {
+		// the pointer is kept only if the Value is set
+		fieldValue.SetZero()
+		state.journal = state.journal[:journaled]
+	}
+	if err == nil && !set {
+		return state.trace.skip(path, field, "no defaulter set the value")
+	}
+	return err
+}
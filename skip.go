@@ -0,0 +1,51 @@
+package defaultz
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SkipFieldPredicate returns true for the fields that shouldn't be visited at all: they are neither defaulted nor
+// recursed into. See [WithSkipFieldPredicate].
+type SkipFieldPredicate func(field reflect.StructField) bool
+
+// WithSkipFieldPredicate adds a predicate for the fields to skip, e.g. the internal fields of generated code.
+// A field is skipped if any of the predicates returns true for it.
+//
+//	defaultz.NewDefaulterRegistry(
+//		defaultz.WithBasicDefaulters(),
+//		defaultz.WithSkipFieldPredicate(defaultz.IsProtobufInternalField),
+//	)
+func WithSkipFieldPredicate(predicate SkipFieldPredicate) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		r.skipPredicates = append(r.skipPredicates, predicate)
+	}
+}
+
+// IsProtobufInternalField is a SkipFieldPredicate for the internal fields of the protobuf-generated structs: the
+// unexported `state`, `sizeCache` and `unknownFields` fields, and the fields with the `XXX_` prefix of the older
+// generators, e.g. `XXX_unrecognized`.
+func IsProtobufInternalField(field reflect.StructField) bool {
+	if strings.HasPrefix(field.Name, "XXX_") {
+		return true
+	}
+	if field.IsExported() {
+		return false
+	}
+	switch field.Name {
+	case "state", "sizeCache", "unknownFields":
+		return true
+	default:
+		return false
+	}
+}
+
+// isSkipped returns true if any of the skip predicates returns true for the field.
+func (r *defaulterRegistry) isSkipped(field reflect.StructField) bool {
+	for _, predicate := range r.skipPredicates {
+		if predicate(field) {
+			return true
+		}
+	}
+	return false
+}
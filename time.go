@@ -0,0 +1,153 @@
+package defaultz
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeDefaulter is a defaulter for time.Time fields.
+//
+// The default value is parsed with the Layout, e.g. `default:"2024-01-01T00:00:00Z"` with the default layout.
+// Unix timestamps are supported as well, with the following markers:
+//
+//   - `default:"@1700000000"` yields the time 1700000000 seconds after the Unix epoch
+//   - `default:"@@1700000000000"` yields the time 1700000000000 milliseconds after the Unix epoch
+//
+// The current time is supported with `default:"now"`, optionally with an offset, e.g. `default:"now+24h"` or
+// `default:"now-1h30m"`. The offset is parsed like the values of [DurationDefaulter], e.g. `now+7d`.
+//
+// The default values without a time zone are parsed in UTC, or in the location named by the [TimeZoneTag] of the
+// field, e.g. `default:"2020-01-01 09:00" tz:"America/New_York"` with the layout "2006-01-02 15:04". The location is
+// loaded with [time.LoadLocation]. The current time and the Unix timestamps are converted to the location.
+type TimeDefaulter struct {
+	// Layout is the layout to parse the default values with. [time.RFC3339] is used if not set.
+	Layout string
+
+	// Now returns the current time for the `now` default values. [time.Now] is used if not set.
+	// It is replaced by the clock of the registry, if the registry has one. See [WithClock].
+	Now func() time.Time
+}
+
+var _ Defaulter = &TimeDefaulter{}
+var _ ClockedDefaulter = &TimeDefaulter{}
+
+// TimeZoneTag is the tag with the name of the location to parse the default value of a time.Time field in.
+// See [TimeDefaulter] for more information.
+const TimeZoneTag = "tz"
+
+func (t *TimeDefaulter) Name() string {
+	return "defaultz.TimeDefaulter"
+}
+
+// WithClock returns a copy of the defaulter with the Now function, which is used by the registry to inject its clock.
+func (t *TimeDefaulter) WithClock(now func() time.Time) Defaulter {
+	clocked := *t
+	clocked.Now = now
+	return &clocked
+}
+
+func (t *TimeDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Struct}
+}
+
+//nolint:lll
+func (t *TimeDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType != reflect.TypeFor[time.Time]() {
+		return true, false, nil
+	}
+
+	var loc *time.Location
+	if name, ok := field.Tag.Lookup(TimeZoneTag); ok {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			msg := fmt.Sprintf("invalid time zone '%s': %v", name, err)
+			return true, false, NewError(t, ErrInvalidDefaultValue, path, field, msg)
+		}
+	}
+
+	timeValue, err := t.parse(value, loc)
+	if err != nil {
+		return true, false, NewError(t, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+
+	// Handle pointer cases
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldType)) // Allocate new time pointer
+		}
+		fieldValue.Elem().Set(reflect.ValueOf(timeValue)) // Set the actual time value
+	} else {
+		fieldValue.Set(reflect.ValueOf(timeValue)) // Direct time assignment
+	}
+
+	return true, true, nil
+}
+
+// parse parses the default value in the location, or as it is if the location is nil.
+func (t *TimeDefaulter) parse(value string, loc *time.Location) (time.Time, error) {
+	timeValue, err := t.parseValue(value, loc)
+	if err != nil || loc == nil {
+		return timeValue, err
+	}
+	return timeValue.In(loc), nil
+}
+
+func (t *TimeDefaulter) parseValue(value string, loc *time.Location) (time.Time, error) {
+	if offset, ok := strings.CutPrefix(value, "now"); ok {
+		now := time.Now
+		if t.Now != nil {
+			now = t.Now
+		}
+		if offset == "" {
+			return now(), nil
+		}
+		if offset[0] != '+' && offset[0] != '-' {
+			return time.Time{}, fmt.Errorf("invalid offset '%s', must start with '+' or '-'", offset)
+		}
+		// the sign is parsed as part of the duration
+		duration, err := parseDuration(offset)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid offset: %w", err)
+		}
+		return now().Add(duration), nil
+	}
+	if timeValue, ok, err := parseUnixTime(value); ok {
+		return timeValue, err
+	}
+
+	layout := t.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	if loc != nil {
+		return time.ParseInLocation(layout, value, loc)
+	}
+	return time.Parse(layout, value)
+}
+
+// parseUnixTime parses the Unix timestamps in seconds with the "@" marker, or in milliseconds with the "@@" marker.
+// It returns false if the value has no marker.
+func parseUnixTime(value string) (time.Time, bool, error) {
+	if millis, ok := strings.CutPrefix(value, "@@"); ok {
+		ms, err := strconv.ParseInt(millis, 10, 64)
+		if err != nil {
+			return time.Time{}, true, fmt.Errorf("invalid Unix milliseconds: %w", err)
+		}
+		return time.UnixMilli(ms).UTC(), true, nil
+	}
+	if seconds, ok := strings.CutPrefix(value, "@"); ok {
+		sec, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil {
+			return time.Time{}, true, fmt.Errorf("invalid Unix seconds: %w", err)
+		}
+		return time.Unix(sec, 0).UTC(), true, nil
+	}
+	return time.Time{}, false, nil
+}
@@ -0,0 +1,130 @@
+package defaultz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TraceOutcome is the outcome of visiting a field, see [TraceEntry].
+type TraceOutcome string
+
+const (
+	// TraceSet is the outcome of the fields that are set with their default values.
+	TraceSet TraceOutcome = "set"
+
+	// TraceSkipped is the outcome of the fields that are left as they are. The reason is in [TraceEntry.Reason].
+	TraceSkipped TraceOutcome = "skipped"
+
+	// TraceRecursed is the outcome of the struct fields, and the slices of structs, whose fields are visited.
+	TraceRecursed TraceOutcome = "recursed"
+
+	// TraceFailed is the outcome of the fields that cannot be defaulted. The error is in [TraceEntry.Error].
+	TraceFailed TraceOutcome = "failed"
+)
+
+// TraceEntry is the decision made for a single field.
+type TraceEntry struct {
+	// Path is the path of the field, e.g. `<root>.Server.Port`.
+	Path string `json:"path"`
+
+	// Type is the type of the field, e.g. `*int`.
+	Type string `json:"type"`
+
+	// Outcome is what happened to the field.
+	Outcome TraceOutcome `json:"outcome"`
+
+	// Reason is the reason a field is skipped.
+	Reason string `json:"reason,omitempty"`
+
+	// Default is the default value of a field that is set, as extracted from the field. With
+	// [WithAlternativeSeparator], it is the alternative that is applied.
+	Default string `json:"default,omitempty"`
+
+	// Value is the default value of a field that is set, after resolving the templates, the constants, the macros and
+	// the generators, as passed to the defaulters.
+	Value string `json:"value,omitempty"`
+
+	// Defaulters are the names of the defaulters that set the field.
+	Defaulters []string `json:"defaulters,omitempty"`
+
+	// Error is the error of a field that failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Trace is the decision log of a single call, returned by [ExtendedDefaulterRegistry.ApplyDefaultsWithTrace].
+// The entries are in the order the fields are visited. It can be serialized, e.g. with [encoding/json], to be
+// attached to a bug report.
+type Trace struct {
+	Entries []TraceEntry `json:"entries"`
+}
+
+// String returns the trace as a human-readable report, one field per line.
+func (t Trace) String() string {
+	var sb strings.Builder
+	for _, entry := range t.Entries {
+		fmt.Fprintf(&sb, "%s (%s): %s", entry.Path, entry.Type, entry.Outcome)
+		switch entry.Outcome {
+		case TraceSet:
+			fmt.Fprintf(&sb, " to %q by %s", entry.Default, strings.Join(entry.Defaulters, ", "))
+		case TraceSkipped:
+			fmt.Fprintf(&sb, ", %s", entry.Reason)
+		case TraceFailed:
+			fmt.Fprintf(&sb, ", %s", entry.Error)
+		case TraceRecursed:
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// add adds an entry for the field. It is a no-op if the trace is nil, i.e. when not tracing.
+func (t *Trace) add(path string, field reflect.StructField, entry TraceEntry) {
+	if t == nil {
+		return
+	}
+	entry.Path = addFieldToPath(path, field)
+	entry.Type = field.Type.String()
+	t.Entries = append(t.Entries, entry)
+}
+
+// skip adds an entry for a skipped field and returns nil, so that it can be used in return statements.
+func (t *Trace) skip(path string, field reflect.StructField, reason string) error {
+	t.add(path, field, TraceEntry{Outcome: TraceSkipped, Reason: reason})
+	return nil
+}
+
+// len returns the number of the entries, 0 if the trace is nil.
+func (t *Trace) len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.Entries)
+}
+
+// tracingDefaulter records the names of the defaulters that set a field.
+type tracingDefaulter struct {
+	Defaulter
+	setBy *[]string
+}
+
+//nolint:lll
+func (t tracingDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	callNext, set, err := t.Defaulter.HandleField(value, path, field, fieldValue)
+	if set {
+		*t.setBy = append(*t.setBy, t.Name())
+	}
+	return callNext, set, err
+}
+
+// traceDefaulters wraps the defaulters to record the names of the ones that set a field in setBy.
+func traceDefaulters(defaulters []DefaulterWithPrecedence, setBy *[]string) []DefaulterWithPrecedence {
+	traced := make([]DefaulterWithPrecedence, len(defaulters))
+	for i, d := range defaulters {
+		traced[i] = DefaulterWithPrecedence{
+			Defaulter:  tracingDefaulter{Defaulter: d.Defaulter, setBy: setBy},
+			Precedence: d.Precedence,
+		}
+	}
+	return traced
+}
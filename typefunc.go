@@ -0,0 +1,84 @@
+package defaultz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeDefaulterFunc returns the default value for a field of the type it is registered for with
+// [WithTypeDefaulterFunc]. The returned value must be assignable to the type.
+type TypeDefaulterFunc func(field reflect.StructField) (reflect.Value, error)
+
+// WithTypeDefaulterFunc sets a function that returns the default values of the fields of the type, and of the pointers
+// to the type. It is a simpler alternative to implementing a [Defaulter] for a single type.
+//
+// The function is called for every zero field of the type, with or without a default value in its tag, and the
+// fields are not passed to the defaulters. The function can read the tags of the field to decide on the value.
+// The errors of the function are returned as [ErrInvalidDefaultValue] errors.
+//
+// Setting a function for a type again overwrites the previous function.
+func WithTypeDefaulterFunc(t reflect.Type, fn TypeDefaulterFunc) DefaulterRegistryOption {
+	return func(r *defaulterRegistry) {
+		if r.typeFuncs == nil {
+			r.typeFuncs = make(map[reflect.Type]TypeDefaulterFunc)
+		}
+		r.typeFuncs[t] = fn
+	}
+}
+
+// typeFunc returns the function registered for the type of the field, if any.
+func (r *defaulterRegistry) typeFunc(field reflect.StructField) (TypeDefaulterFunc, bool) {
+	if len(r.typeFuncs) == 0 {
+		return nil, false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fn, ok := r.typeFuncs[t]
+	return fn, ok
+}
+
+// applyTypeFunc sets the field to the value returned by the function registered for its type.
+//
+//nolint:lll
+func (r *defaulterRegistry) applyTypeFunc(state *applyState, path string, field reflect.StructField, fieldValue reflect.Value, fn TypeDefaulterFunc) error {
+	if r.defaultNilOnly && !isNillable(fieldValue.Kind()) {
+		// zero values are explicit values, only nil ones are defaulted
+		return state.trace.skip(path, field, "not nil-able")
+	}
+	if !r.overwriteExisting && !isZero(fieldValue) {
+		// we do not overwrite non-zero values
+		return state.trace.skip(path, field, "non-zero value")
+	}
+	if !fieldValue.CanSet() {
+		if r.ignoreCannotSet {
+			return state.trace.skip(path, field, "cannot set field")
+		}
+		return NewError(nil, ErrCannotSetField, path, field, "cannot set field")
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	value, err := fn(field)
+	if err != nil {
+		return NewError(nil, ErrInvalidDefaultValue, path, field, err.Error())
+	}
+	if !value.IsValid() || !value.Type().AssignableTo(fieldType) {
+		msg := fmt.Sprintf("function of %s returned a value of type %v", fieldType, typeOf(value))
+		return NewError(nil, ErrInvalidDefaultValue, path, field, msg)
+	}
+
+	state.record(fieldValue)
+	if fieldValue.Kind() == reflect.Ptr {
+		fieldValue.Set(reflect.New(fieldType))
+		fieldValue.Elem().Set(value)
+	} else {
+		fieldValue.Set(value)
+	}
+	state.trace.add(path, field, TraceEntry{Outcome: TraceSet})
+	return nil
+}
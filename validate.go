@@ -0,0 +1,74 @@
+package defaultz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TypeErrors are the errors of validating multiple types, keyed by the types.
+// See [ExtendedDefaulterRegistry.ValidateTypes] for more information.
+type TypeErrors map[reflect.Type]error
+
+// Error returns the errors of the types, one per line, sorted by the names of the types.
+func (e TypeErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for t, err := range e {
+		lines = append(lines, fmt.Sprintf("%s: %v", t, err))
+	}
+	sort.Strings(lines)
+	return fmt.Sprintf("%d types are invalid:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// Unwrap returns the errors of the types, so that [errors.Is] and [errors.As] match any of them.
+func (e TypeErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Validate checks the default values in the tags of the struct type, or the pointer to struct type, by applying them
+// to a new zero value of the type. It returns the error ApplyDefaults would return for a zero value, e.g. for a
+// default value that cannot be parsed, so that the tags can be checked at startup or in tests.
+//
+// The references and the generators are not resolved, as the generators may have side effects, e.g. reading secrets
+// or generating random values. Such default values are checked only when they are applied.
+func (r *defaulterRegistry) Validate(t reflect.Type) error {
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.New("type must be a struct, or a pointer to a struct")
+	}
+	return r.apply(reflect.New(t).Interface(), &applyState{validating: true})
+}
+
+// ValidateTypes validates the types like [ExtendedDefaulterRegistry.Validate], concurrently. The errors are returned as
+// [TypeErrors], keyed by the types as they are given. It returns nil if all the types are valid.
+func (r *defaulterRegistry) ValidateTypes(types ...reflect.Type) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(TypeErrors)
+	for _, t := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.Validate(t); err != nil {
+				mu.Lock()
+				errs[t] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
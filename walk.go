@@ -0,0 +1,200 @@
+package defaultz
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WalkFunc is called by [Walk] for each field of the structs in the tree, with the path of the field, e.g.
+// `pkg.(Config).Server.Port`. Returning [SkipField] skips the nested structs of the field, and returning any other
+// error stops the walk.
+type WalkFunc func(path string, field reflect.StructField, value reflect.Value) error
+
+// SkipField is returned by a [WalkFunc] to skip the nested structs of the field. It is not returned by [Walk].
+//
+//nolint:gochecknoglobals	// a sentinel like fs.SkipDir, which is not an error to report
+var SkipField = errors.New("skip this field")
+
+// Walk calls the function for each field of the struct, walking into the nested structs with the same rules as
+// [DefaulterRegistry.ApplyDefaults]: the type must not have cycles, nil pointers to structs are allocated if they can
+// be set, and the existing elements of the slices, arrays and maps of structs are walked into. The values of the maps
+// are walked in a copy, which is written back to the map afterward if it is changed. The structs without exported
+// fields, e.g. time.Time, are not walked into.
+//
+// The fields are visited depth-first in the order of their declaration, a field before its nested fields. Unexported
+// fields are visited as well, but they cannot be set.
+//
+// This allows building other passes over the structs, e.g. validation or redaction, with the same structure rules:
+//
+//	err := defaultz.Walk(&cfg, func(path string, field reflect.StructField, value reflect.Value) error {
+//		if field.Tag.Get("secret") == "true" && value.CanSet() {
+//			value.SetZero()
+//		}
+//		return nil
+//	})
+func Walk(obj interface{}, visit WalkFunc) error {
+	val, err := checkRoot(obj)
+	if err != nil {
+		return err
+	}
+	return walkStruct(walkFuncVisitor(visit), val.Elem(), typePath(val.Elem().Type()))
+}
+
+// checkRoot returns the value of the object, if it is a pointer to a struct whose type definition allows no cycles.
+func checkRoot(obj interface{}) (reflect.Value, error) {
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("object must be a pointer to a struct")
+	}
+
+	// check if the type definition allows cycles
+	if detectPotentialCycles(val.Elem().Type(), make(map[reflect.Type]bool)) {
+		return reflect.Value{}, errors.New("type definition must not have cycles")
+	}
+	return val, nil
+}
+
+// typePath returns the path of a root struct of the type, e.g. `pkg.(Config)`, or `<root>` for anonymous structs.
+func typePath(t reflect.Type) string {
+	if t.Name() == "" {
+		return "<root>"
+	}
+	return fmt.Sprintf("%s.(%s)", t.PkgPath(), t.Name())
+}
+
+// visitor is called by walkStruct for the structs in a tree.
+type visitor interface {
+	// visitField is called for each field of a struct with the path and the type of the struct. It calls walk to walk
+	// into the nested structs of the field, if it wants to.
+	visitField(path string, parent reflect.Type, field reflect.StructField, value reflect.Value, walk func() error) error
+
+	// leaveStruct is called after the fields of a struct are visited.
+	leaveStruct(path string, value reflect.Value) error
+
+	// setMapIndex writes the copy of a map value back to the map, after the copy is walked.
+	setMapIndex(m reflect.Value, key reflect.Value, elem reflect.Value)
+}
+
+// walkStruct visits the fields of the struct value.
+func walkStruct(v visitor, value reflect.Value, path string) error {
+	t := value.Type()
+	for i := range value.NumField() {
+		field := t.Field(i)
+		fieldValue := value.Field(i)
+		walk := func() error {
+			return walkValue(v, fieldValue, addFieldToPath(path, field))
+		}
+		if err := v.visitField(path, t, field, fieldValue, walk); err != nil {
+			return err
+		}
+	}
+	return v.leaveStruct(path, value)
+}
+
+// walkValue walks into the structs in the value: the value itself, the struct it points to, or the existing elements
+// of the collections of structs, see isStructCollection. Nil pointers are not walked into.
+func walkValue(v visitor, value reflect.Value, path string) error {
+	//nolint:exhaustive			// there's a default case and we don't want to add meaningless cases
+	switch value.Kind() {
+	case reflect.Struct:
+		return walkStruct(v, value, path)
+	case reflect.Ptr:
+		if value.IsNil() || value.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		return walkStruct(v, value.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if !isStructCollection(value.Type()) {
+			return nil
+		}
+		for i := range value.Len() {
+			if err := walkValue(v, value.Index(i), addIndexToPath(path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !isStructCollection(value.Type()) {
+			return nil
+		}
+		// sort the keys, so that the walk, and the errors, are deterministic
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			if err := walkMapElement(v, value, key, addKeyToPath(path, key)); err != nil {
+				return err
+			}
+		}
+	default:
+		// nothing to walk into
+	}
+	return nil
+}
+
+// walkMapElement walks into the value of the key of the map. The values of maps are not addressable, so the structs
+// and the arrays are walked in a copy, which is written back to the map.
+func walkMapElement(v visitor, m reflect.Value, key reflect.Value, path string) error {
+	elem := m.MapIndex(key)
+	if elem.Kind() != reflect.Struct && elem.Kind() != reflect.Array {
+		// slices and pointers refer to their elements, which are walked in place
+		return walkValue(v, elem, path)
+	}
+	if !m.CanInterface() {
+		// the maps of unexported fields cannot be written to
+		return nil
+	}
+
+	elemCopy := reflect.New(elem.Type()).Elem()
+	elemCopy.Set(elem)
+	err := walkValue(v, elemCopy, path)
+	// the changes made before an error are kept, like the ones of the other fields, and the map is not written to if
+	// there are no changes, so that the maps are only read by the visits that don't change anything
+	if !reflect.DeepEqual(elem.Interface(), elemCopy.Interface()) {
+		v.setMapIndex(m, key, elemCopy)
+	}
+	return err
+}
+
+// walkFuncVisitor adapts a WalkFunc to a visitor.
+type walkFuncVisitor WalkFunc
+
+//nolint:lll
+func (f walkFuncVisitor) visitField(path string, _ reflect.Type, field reflect.StructField, value reflect.Value, walk func() error) error {
+	if err := f(addFieldToPath(path, field), field, value); err != nil {
+		if errors.Is(err, SkipField) {
+			return nil
+		}
+		return err
+	}
+
+	if !hasWalkableFields(field.Type) {
+		// nothing to walk into, e.g. the unexported fields of a time.Time
+		return nil
+	}
+
+	// allocate the nil pointers to structs, so that their fields are visited
+	isNilStructPtr := value.Kind() == reflect.Ptr && value.IsNil() && field.Type.Elem().Kind() == reflect.Struct
+	if isNilStructPtr && value.CanSet() {
+		value.Set(reflect.New(field.Type.Elem()))
+	}
+	return walk()
+}
+
+func (f walkFuncVisitor) leaveStruct(_ string, _ reflect.Value) error {
+	return nil
+}
+
+func (f walkFuncVisitor) setMapIndex(m reflect.Value, key reflect.Value, elem reflect.Value) {
+	m.SetMapIndex(key, elem)
+}
+
+// hasWalkableFields returns true if the structs in the type, or the ones in the collections, have exported fields.
+func hasWalkableFields(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && hasExportedFields(t)
+}